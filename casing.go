@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Casing string
+
+// Casing of name fields
+const (
+	RAW   Casing = "raw"   // As in source file, Posti's files are upper case
+	LOWER Casing = "lower" // Lower case
+	TITLE Casing = "title" // Proper case, for example "Kustaa III:n katu" and "Etelä-Haaga"
+)
+
+// Parse casing name, empty is LOWER
+func ParseCasing(s string) (Casing, error) {
+	switch Casing(s) {
+	case ``:
+		return LOWER, nil
+	case RAW, LOWER, TITLE:
+		return Casing(s), nil
+	}
+
+	return ``, fmt.Errorf(`unknown casing '%s', expected raw, lower or title`, s)
+}
+
+// Words written in lower case when not first in a name
+// Particles and generic street terms, for example "Aleksis Kiven katu" and "Aleksis Kivis gata".
+var titleLowerWords = map[string]bool{
+	// Finnish
+	`ja`: true, `katu`: true, `tie`: true, `kuja`: true, `polku`: true, `tori`: true, `aukio`: true,
+	`kaari`: true, `rinne`: true, `raitti`: true, `väylä`: true, `ranta`: true, `silta`: true,
+	`piha`: true, `puisto`: true, `puistotie`: true, `linja`: true, `mlk`: true,
+	// Swedish
+	`av`: true, `af`: true, `von`: true, `van`: true, `der`: true, `de`: true, `i`: true, `på`: true,
+	`till`: true, `vid`: true, `och`: true, `gata`: true, `gatan`: true, `väg`: true, `vägen`: true,
+	`gränd`: true, `stig`: true, `torg`: true, `allé`: true, `backe`: true, `kaj`: true, `bro`: true,
+	`park`: true, `linjen`: true,
+}
+
+// Regnal and ordinal numbers up to 39 with optional case ending, for example "III:n" and "XII"
+var titleRomanNumeral = regexp.MustCompile(`^(X{0,3}(IX|IV|V?I{0,3}))(:.*)?$`)
+
+// Word is a Roman numeral, a lone "I" is only a numeral with a case ending as "i" is a Swedish particle
+func isRomanNumeral(upper string) bool {
+	m := titleRomanNumeral.FindStringSubmatch(upper)
+	if m == nil || m[1] == `` {
+		return false
+	}
+
+	return m[1] != `I` || m[3] != ``
+}
+
+// Proper case of Finnish or Swedish name
+// Every word and every part of a hyphenated word starts with an upper case letter, particles and
+// generic street terms after the first word are lower case and Roman numerals are upper case.
+func TitleCase(s string) string {
+	words := strings.Split(strings.ToLower(s), ` `)
+
+	first := true
+	for i, word := range words {
+		if word == `` {
+			continue
+		}
+
+		if upper := strings.ToUpper(word); isRomanNumeral(upper) {
+			// Case ending stays lower case
+			numeral := upper
+			if idx := strings.IndexByte(upper, ':'); idx >= 0 {
+				numeral = upper[:idx] + word[idx:]
+			}
+
+			words[i] = numeral
+			first = false
+			continue
+		}
+
+		if !first && titleLowerWords[word] {
+			first = false
+			continue
+		}
+
+		parts := strings.Split(word, `-`)
+		for j, part := range parts {
+			parts[j] = upperFirst(part)
+		}
+
+		words[i] = strings.Join(parts, `-`)
+		first = false
+	}
+
+	return strings.Join(words, ` `)
+}
+
+// First letter in upper case, leading punctuation such as ( is skipped
+func upperFirst(s string) string {
+	for i, r := range s {
+		if unicode.IsLetter(r) {
+			return s[:i] + string(unicode.ToUpper(r)) + s[i+utf8.RuneLen(r):]
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Sorts names by the alphabetical order of a language
+type Collator struct {
+	alphabet    []rune        // Letters in alphabetical order
+	equivalents map[rune]rune // Letters sorted as another letter, for example w as v
+}
+
+// Letters with diacritics which are sorted as their base letter in both languages
+var collationFoldings = map[rune]rune{
+	'à': 'a', 'á': 'a', 'â': 'a', 'ã': 'a',
+	'ç': 'c',
+	'è': 'e', 'é': 'e', 'ê': 'e', 'ë': 'e',
+	'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i',
+	'ñ': 'n',
+	'ò': 'o', 'ó': 'o', 'ô': 'o', 'õ': 'o',
+	'š': 's',
+	'ù': 'u', 'ú': 'u', 'û': 'u',
+	'ü': 'y', 'ý': 'y', 'ÿ': 'y',
+	'ž': 'z',
+	'æ': 'ä',
+	'ø': 'ö',
+}
+
+// Finnish alphabet, v and w are the same letter and å ä ö are last
+var FinnishCollator = NewCollator(`abcdefghijklmnopqrstuvwxyzåäö`, map[rune]rune{'w': 'v'})
+
+// Swedish alphabet, å ä ö are last
+var SwedishCollator = NewCollator(`abcdefghijklmnopqrstuvwxyzåäö`, nil)
+
+func NewCollator(alphabet string, equivalents map[rune]rune) *Collator {
+	c := &Collator{
+		alphabet:    []rune(alphabet),
+		equivalents: make(map[rune]rune),
+	}
+
+	for from, to := range collationFoldings {
+		c.equivalents[from] = to
+	}
+
+	for from, to := range equivalents {
+		c.equivalents[from] = to
+	}
+
+	return c
+}
+
+// Parse collation language, ISO 639-1 code "fi" or "sv"
+// "se" is a deprecated alias of "sv" and is returned as "sv".
+func ParseSortLanguage(lang string) (string, error) {
+	switch lang {
+	case `fi`, `sv`:
+		return lang, nil
+	case `se`:
+		return `sv`, nil
+	}
+
+	return ``, fmt.Errorf(`unknown collation language: '%s', expected fi or sv`, lang)
+}
+
+// Get collator for language code "fi" or "sv", or deprecated "se"
+func CollatorFor(lang string) (*Collator, error) {
+	lang, err := ParseSortLanguage(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	if lang == `sv` {
+		return SwedishCollator, nil
+	}
+
+	return FinnishCollator, nil
+}
+
+// Sort weight of a lower case rune
+// Spaces, digits and punctuation are sorted before the alphabet and other runes after it by code point
+func (c *Collator) weight(r rune) int {
+	for idx, letter := range c.alphabet {
+		if letter == r {
+			return idx
+		}
+	}
+
+	if r < 'a' {
+		return int(r) - 'a'
+	}
+
+	return len(c.alphabet) + int(r)
+}
+
+// Compare strings, returns -1, 0 or 1
+// Letters are first compared as equivalent letters and case-insensitively,
+// then as written and finally lower case is sorted before upper case
+func (c *Collator) Compare(a string, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+
+	// Primary: equivalent letters are equal
+	cmp := c.compareLevel(ra, rb, func(r rune) int {
+		r = unicode.ToLower(r)
+		if eq, ok := c.equivalents[r]; ok {
+			r = eq
+		}
+		return c.weight(r)
+	})
+	if cmp != 0 {
+		return cmp
+	}
+
+	// Secondary: letters as written
+	cmp = c.compareLevel(ra, rb, func(r rune) int {
+		return c.weight(unicode.ToLower(r))
+	})
+	if cmp != 0 {
+		return cmp
+	}
+
+	// Tertiary: lower case first
+	cmp = c.compareLevel(ra, rb, func(r rune) int {
+		if unicode.IsUpper(r) {
+			return 1
+		}
+		return 0
+	})
+	if cmp != 0 {
+		return cmp
+	}
+
+	return strings.Compare(a, b)
+}
+
+func (c *Collator) compareLevel(ra []rune, rb []rune, weight func(r rune) int) int {
+	for i := 0; i < len(ra) && i < len(rb); i++ {
+		wa := weight(ra[i])
+		wb := weight(rb[i])
+		if wa < wb {
+			return -1
+		} else if wa > wb {
+			return 1
+		}
+	}
+
+	if len(ra) < len(rb) {
+		return -1
+	} else if len(ra) > len(rb) {
+		return 1
+	}
+
+	return 0
+}
+
+func (c *Collator) Less(a string, b string) bool {
+	return c.Compare(a, b) < 0
+}
@@ -0,0 +1,581 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Check that required flags of fs were given
+func requireFlags(fs *flag.FlagSet, required ...string) error {
+	seen := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		seen[f.Name] = true
+	})
+
+	return HasRequiredCommandLineArguments(required, seen)
+}
+
+// Print v as indented JSON to standard output
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func convert(fs *flag.FlagSet, args []string) int {
+	sourceFile := fs.String("f", "", "File name (BAF_yyyymmdd.dat)")
+	outputDirectory := fs.String("o", "", "Output directory /home/user/jsonfiles")
+	deterministic := fs.Bool("deterministic", false, "Sorted and reproducible output, same input gives byte-identical files")
+	pretty := fs.Bool("pretty", false, "Pretty-print JSON")
+	sortLanguage := fs.String("collate", "fi", "Sort deterministic output by Finnish (fi) or Swedish (sv) names, se is a deprecated alias of sv")
+	casingName := fs.String("casing", "lower", "Casing of names, raw (as in source file), lower or title (for example 'Kustaa III:n katu')")
+	schemaName := fs.String("schema", "v1", "Output schema, v1 or v2 ('sv' for Swedish, municipality.json as an object, JSON Schema documents in /schema)")
+	var layouts layoutFlags
+	fs.Var(&layouts, "layout", "Output file <kind>=<template>, kind is municipality, postnumber or street, template may contain {municipality} and {postal}, for example street=postal/{postal}.json. Can be repeated. 'default' is the /<municipality>/<postal>/street.json tree")
+	incremental := fs.Bool("incremental", false, "Keep unchanged files of the previous output with their modification times, only changed files are written")
+	maxMemoryMiB := fs.Int64("max-memory", 0, "Low-memory mode, convert in partitions spilled to temporary files to stay roughly under this many MiB, 0 converts everything in memory")
+	releases := fs.Bool("releases", false, "Write to <output>/<RunningDate>, point <output>/latest to it and record it in <output>/releases.json")
+	keepReleases := fs.Int("keep", 0, "With -releases prune releases beyond this count, 0 keeps all")
+	maxReleaseAgeDays := fs.Int("max-age-days", 0, "With -releases prune releases with running date older than this many days, 0 keeps all")
+	maxShrink := fs.Float64("max-shrink", 10, "Refuse to publish when record, municipality or postal code count drops more than this many percent from the previous output")
+	force := fs.Bool("force", false, "Publish even if counts dropped more than -max-shrink")
+	provenance := fs.Bool("provenance", false, "List source line ranges of contributing records in every entry as \"lines\"")
+	filter := addFilterFlags(fs)
+	fs.Parse(args)
+
+	err := requireFlags(fs, "f", "o")
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	if *maxShrink <= 0 || *maxShrink > 100 {
+		return usageError(fs, "Invalid -max-shrink %v, expected a percentage above 0 and at most 100", *maxShrink)
+	}
+
+	if *sortLanguage == `se` {
+		log.Printf(`Warning: -collate se is deprecated, use -collate sv`)
+	}
+
+	*sortLanguage, err = ParseSortLanguage(*sortLanguage)
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	recordFilter, err := filter()
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	casing, err := ParseCasing(*casingName)
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	schemaVersion, err := ParseSchemaVersion(*schemaName)
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	// Generation time of reproducible output, https://reproducible-builds.org/specs/source-date-epoch/
+	var generated time.Time
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return usageError(fs, "Invalid SOURCE_DATE_EPOCH '%s'", epoch)
+		}
+
+		generated = time.Unix(seconds, 0)
+	}
+
+	log.Printf("Source file: '%s'", *sourceFile)
+	log.Printf("Output directory: '%s'", *outputDirectory)
+
+	starttime := time.Now().UTC()
+
+	options := ConvertOptions{
+		Deterministic: *deterministic,
+		Pretty:        *pretty,
+		SortLanguage:  *sortLanguage,
+		Layouts:       layouts,
+		Incremental:   *incremental,
+		MaxMemory:     *maxMemoryMiB * 1024 * 1024,
+		Releases:      *releases,
+		KeepReleases:  *keepReleases,
+		MaxReleaseAge: time.Duration(*maxReleaseAgeDays) * 24 * time.Hour,
+		Filter:        recordFilter,
+		Casing:        casing,
+		Schema:        schemaVersion,
+		Generated:     generated,
+		Provenance:    *provenance,
+		MaxShrink:     *maxShrink,
+	}
+
+	if *force {
+		options.MaxShrink = 0
+	}
+
+	// Stop on Ctrl+C, previous output is left untouched
+	ctx, stop := interruptContext()
+	defer stop()
+
+	_, err = ConvertFile(ctx, *sourceFile, *outputDirectory, options)
+	if err != nil {
+		return fail(err)
+	}
+
+	log.Printf("Took %s", time.Now().UTC().Sub(starttime))
+	return ExitOK
+}
+
+func stats(fs *flag.FlagSet, args []string) int {
+	sourceFile := fs.String("f", "", "File name (BAF_yyyymmdd.dat)")
+	format := fs.String("format", "text", "Output format text, json or html")
+	filter := addFilterFlags(fs)
+	fs.Parse(args)
+
+	err := requireFlags(fs, "f")
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	var write func(stats DatasetStatsJSON) error
+
+	switch *format {
+	case "text":
+		write = func(stats DatasetStatsJSON) error {
+			return WriteStatsText(os.Stdout, stats)
+		}
+	case "json":
+		write = func(stats DatasetStatsJSON) error {
+			return printJSON(stats)
+		}
+	case "html":
+		write = func(stats DatasetStatsJSON) error {
+			return WriteStatsHTML(os.Stdout, stats)
+		}
+	default:
+		return usageError(fs, "Unknown format '%s'", *format)
+	}
+
+	recordFilter, err := filter()
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	stats, err := DatasetStats(ctx, *sourceFile, recordFilter)
+	if err != nil {
+		return fail(err)
+	}
+
+	err = write(stats)
+	if err != nil {
+		return fail(err)
+	}
+
+	return ExitOK
+}
+
+func validate(fs *flag.FlagSet, args []string) int {
+	sourceFile := fs.String("f", "", "File name (BAF_yyyymmdd.dat)")
+	fs.Parse(args)
+
+	err := requireFlags(fs, "f")
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	records := 0
+	err = ReadSourceFileContext(ctx, *sourceFile, func(addr StreetAddress) error {
+		records++
+		return nil
+	})
+
+	if err != nil {
+		return fail(err)
+	}
+
+	fmt.Printf("OK, %d records\n", records)
+	return ExitOK
+}
+
+func lint(fs *flag.FlagSet, args []string) int {
+	sourceFile := fs.String("f", "", "File name (BAF_yyyymmdd.dat)")
+	configFile := fs.String("config", "", "JSON file disabling rules, overriding severities and ignoring findings")
+	minSeverity := fs.String("severity", "info", "Report findings of at least this severity, info, warning or error")
+	format := fs.String("format", "text", "Output format text or json")
+	fs.Parse(args)
+
+	err := requireFlags(fs, "f")
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	min, err := ParseSeverity(*minSeverity)
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	if *format != "text" && *format != "json" {
+		return usageError(fs, "Unknown format '%s'", *format)
+	}
+
+	var config LintConfigJSON
+	if *configFile != "" {
+		config, err = ReadLintConfig(*configFile)
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	findings, err := LintFile(ctx, *sourceFile, config)
+	if err != nil {
+		return fail(err)
+	}
+
+	reported := []LintFindingJSON{}
+	counts := make(map[Severity]int)
+	for _, finding := range findings {
+		if finding.Severity.AtLeast(min) {
+			reported = append(reported, finding)
+			counts[finding.Severity]++
+		}
+	}
+
+	if *format == "json" {
+		err = printJSON(reported)
+		if err != nil {
+			return fail(err)
+		}
+	} else {
+		for _, finding := range reported {
+			fmt.Printf("line %d: %s [%s] %s\n", finding.Line, finding.Severity, finding.Rule, finding.Message)
+		}
+	}
+
+	log.Printf("%d errors, %d warnings, %d info", counts[ERROR], counts[WARNING], counts[INFO])
+
+	if counts[ERROR] > 0 {
+		return ExitDataError
+	}
+
+	return ExitOK
+}
+
+func diff(fs *flag.FlagSet, args []string) int {
+	sourceFile := fs.String("f", "", "File name (BAF_yyyymmdd.dat)")
+	previousFile := fs.String("d", "", "Previous release file (BAF_yyyymmdd.dat) to compare -f against")
+	fs.Parse(args)
+
+	err := requireFlags(fs, "f", "d")
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	log.Printf("Source file: '%s'", *sourceFile)
+	log.Printf("Previous file: '%s'", *previousFile)
+
+	starttime := time.Now().UTC()
+
+	changes, err := DiffFiles(*previousFile, *sourceFile)
+	if err != nil {
+		return fail(err)
+	}
+
+	err = printJSON(changes)
+	if err != nil {
+		return fail(err)
+	}
+
+	log.Printf("Took %s", time.Now().UTC().Sub(starttime))
+	return ExitOK
+}
+
+func lookup(fs *flag.FlagSet, args []string) int {
+	sourceFile := fs.String("f", "", "File name (BAF_yyyymmdd.dat)")
+	casingName := fs.String("casing", "lower", "Casing of names, raw (as in source file), lower or title")
+	filter := addFilterFlags(fs)
+	fs.Parse(args)
+
+	err := requireFlags(fs, "f")
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	recordFilter, err := filter()
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	if recordFilter.IsEmpty() {
+		return usageError(fs, "At least one filter is required")
+	}
+
+	casing, err := ParseCasing(*casingName)
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	results, err := LookupStreets(ctx, *sourceFile, recordFilter, casing)
+	if err != nil {
+		return fail(err)
+	}
+
+	if results == nil {
+		results = []LookupJSON{}
+	}
+
+	err = printJSON(results)
+	if err != nil {
+		return fail(err)
+	}
+
+	return ExitOK
+}
+
+// Print source lines of a street decoded field by field
+func trace(fs *flag.FlagSet, args []string) int {
+	sourceFile := fs.String("f", "", "File name (BAF_yyyymmdd.dat)")
+	postalCode := fs.String("postal", "", "Postal code, for example 00100")
+	street := fs.String("street", "", "Street name in Finnish or Swedish, case-insensitive")
+	fs.Parse(args)
+
+	err := requireFlags(fs, "f", "postal", "street")
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	found := 0
+	err = TraceFile(ctx, *sourceFile, *postalCode, *street, func(line int64, record []byte) error {
+		found++
+		return WriteTraceRecord(os.Stdout, line, record)
+	})
+
+	if err != nil {
+		return fail(err)
+	}
+
+	if found == 0 {
+		fmt.Fprintf(os.Stderr, "No lines with postal code %s and street '%s'\n", *postalCode, *street)
+		return ExitFailure
+	}
+
+	return ExitOK
+}
+
+func extract(fs *flag.FlagSet, args []string) int {
+	sourceFile := fs.String("f", "", "File name (BAF_yyyymmdd.dat)")
+	outputFile := fs.String("o", "", "Output file, standard output if not given")
+	filter := addFilterFlags(fs)
+	sampleRate := fs.Float64("sample", 0, "Keep this fraction of matching records, for example 0.01 for 1%, 0 keeps all")
+	seed := fs.Int64("seed", 1, "Random seed for -sample, the same seed gives the same sample")
+	fs.Parse(args)
+
+	err := requireFlags(fs, "f")
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	options := ExtractOptions{
+		SampleRate: *sampleRate,
+		Seed:       *seed,
+	}
+
+	if options.SampleRate < 0 || options.SampleRate > 1 {
+		return usageError(fs, "Invalid sample rate %v, expected 0..1", options.SampleRate)
+	}
+
+	options.Filter, err = filter()
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	if *outputFile == "" {
+		stats, err := ExtractFile(ctx, *sourceFile, os.Stdout, options)
+		if err != nil {
+			return fail(err)
+		}
+
+		log.Printf("Extracted %d / %d records", stats.Matched, stats.Records)
+		return ExitOK
+	}
+
+	// Written next to the output file and renamed in place, so a failed extract leaves no partial file
+	f, err := ioutil.TempFile(filepath.Dir(*outputFile), "."+filepath.Base(*outputFile)+".tmp-")
+	if err != nil {
+		return fail(err)
+	}
+
+	stats, err := ExtractFile(ctx, *sourceFile, f, options)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), *outputFile)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fail(err)
+	}
+
+	log.Printf("Extracted %d / %d records to '%s'", stats.Matched, stats.Records, *outputFile)
+	return ExitOK
+}
+
+func serve(fs *flag.FlagSet, args []string) int {
+	dir := fs.String("d", "", "Output directory /home/user/jsonfiles")
+	addr := fs.String("addr", "localhost:8080", "Listen address")
+	fs.Parse(args)
+
+	err := requireFlags(fs, "d")
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	fInfo, err := os.Stat(*dir)
+	if err != nil {
+		return fail(err)
+	}
+
+	if !fInfo.IsDir() {
+		return usageError(fs, "'%s' is not a directory", *dir)
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	err = Serve(ctx, *dir, *addr)
+	if err != nil {
+		return fail(err)
+	}
+
+	return ExitOK
+}
+
+func verify(fs *flag.FlagSet, args []string) int {
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return usageError(fs, "Output directory is required")
+	}
+
+	report, err := VerifyDirectory(fs.Arg(0))
+	if err != nil {
+		return fail(err)
+	}
+
+	for _, f := range report.Missing {
+		fmt.Printf("missing: %s\n", f)
+	}
+
+	for _, f := range report.Extra {
+		fmt.Printf("extra: %s\n", f)
+	}
+
+	for _, f := range report.Modified {
+		fmt.Printf("modified: %s\n", f)
+	}
+
+	if !report.OK() {
+		return ExitFailure
+	}
+
+	fmt.Println("OK")
+	return ExitOK
+}
+
+// Markers around the generated field table in README
+const (
+	fieldsBegin = "<!-- fields -->\n"
+	fieldsEnd   = "<!-- /fields -->"
+)
+
+// Print source file field table generated from RawLineStructure, or update it in README
+func fields(fs *flag.FlagSet, args []string) int {
+	readme := fs.String("readme", "", "Replace the table between "+strings.TrimSpace(fieldsBegin)+" and "+fieldsEnd+" in this file")
+	fs.Parse(args)
+
+	table, err := FixedLayoutTable(reflect.TypeOf(RawLineStructure{}))
+	if err != nil {
+		return fail(err)
+	}
+
+	if *readme == "" {
+		fmt.Print(table)
+		return ExitOK
+	}
+
+	b, err := ioutil.ReadFile(*readme)
+	if err != nil {
+		return fail(err)
+	}
+
+	s := string(b)
+	begin := strings.Index(s, fieldsBegin)
+	end := strings.Index(s, fieldsEnd)
+	if begin == -1 || end < begin {
+		return fail(fmt.Errorf("markers not found in '%s'", *readme))
+	}
+
+	s = s[:begin+len(fieldsBegin)] + table + s[end:]
+
+	err = ioutil.WriteFile(*readme, []byte(s), os.FileMode(0644))
+	if err != nil {
+		return fail(err)
+	}
+
+	return ExitOK
+}
+
+// Print JSON Schema document of an output file type
+func schema(fs *flag.FlagSet, args []string) int {
+	schemaName := fs.String("schema", "v2", "Output schema, v1 or v2")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return usageError(fs, "File type is required: %s", strings.Join(SchemaFileTypes, ", "))
+	}
+
+	schemaVersion, err := ParseSchemaVersion(*schemaName)
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	doc, err := JSONSchema(fs.Arg(0), schemaVersion)
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	err = printJSON(doc)
+	if err != nil {
+		return fail(err)
+	}
+
+	return ExitOK
+}
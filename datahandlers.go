@@ -2,18 +2,15 @@ package main
 
 import (
 	"bytes"
-	"encoding/binary"
+	"context"
 	"encoding/json"
-	"errors"
-	"github.com/djimenez/iconv-go"
+	"fmt"
 	"github.com/spf13/afero"
-	"io"
 	"io/ioutil"
 	"log"
 	"os"
-	"path"
-	"runtime"
-	"strings"
+	"path/filepath"
+	"sort"
 	"time"
 )
 
@@ -37,7 +34,7 @@ type Building struct {
 
 type StreetAddress struct {
 	//RecordIdentifier              string // #1
-	//RunningDate                    string // #2
+	RunningDate           string // #2 Running date, numeric date yyyymmdd
 	PostalCode            string // #3 Postal code, numeric
 	PostalCodeNameFi      string // #4 Postal code name in Finnish
 	PostalCodeNameSe      string // #5 Postal code name in Swedish
@@ -55,6 +52,8 @@ type StreetAddress struct {
 	MunicipalityCode   string // #25 Municipality code, numeric
 	MunicipalityNameFi string // #26 Municipality name in Finnish
 	MunicipalityNameSe string // #27 Municipality name in Swedish
+
+	Line int64 // 1-based line number in source file, 0 if unknown
 }
 
 // Converters
@@ -76,212 +75,322 @@ func (src StreetAddress) StreetNumberMinMax(arr []int64) (min int64, max int64)
 	return GetMinMaxArray(numbers, -1)
 }
 
-func (src *RawLineStructure) ToStreet(converter *iconv.Converter) StreetAddress {
-	smallest := Building{
-		BuildingNumber1:         StringToInt64(BytesToString(src.SmallestBuildingNumber1[:], converter)),        // 14
-		BuildingDeliveryLetter1: StringToByte(BytesToString(src.SmallestBuildingDeliveryLetter1[:], converter)), // 15
-		PunctuationMark:         StringToByte(BytesToString(src.SmallestPunctuationMark[:], converter)),         // 16
-		BuildingNumber2:         StringToInt64(BytesToString(src.SmallestBuildingNumber2[:], converter)),        // 17
-		BuildingDeliveryLetter2: StringToByte(BytesToString(src.SmallestBuildingDeliveryLetter2[:], converter)), // 18
-	}
-
-	highest := Building{
-		BuildingNumber1:         StringToInt64(BytesToString(src.HighestBuildingNumber1[:], converter)),        // 20
-		BuildingDeliveryLetter1: StringToByte(BytesToString(src.HighestBuildingDeliveryLetter1[:], converter)), // 21
-		PunctuationMark:         StringToByte(BytesToString(src.HighestPunctuationMark[:], converter)),         // 22
-		BuildingNumber2:         StringToInt64(BytesToString(src.HighestBuildingNumber2[:], converter)),        // 23
-		BuildingDeliveryLetter2: StringToByte(BytesToString(src.HighestBuildingDeliveryLetter2[:], converter)), // 24
-	}
-
-	p := StreetAddress{
-		PostalCode:              BytesToString(src.PostalCode[:], converter),                             // 3
-		PostalCodeNameFi:        strings.ToLower(BytesToString(src.PostalCodeNameFi[:], converter)),      // 4
-		PostalCodeNameSe:        strings.ToLower(BytesToString(src.PostalCodeNameSe[:], converter)),      // 5
-		PostalCodeShortNameFi:   strings.ToLower(BytesToString(src.PostalCodeShortNameFi[:], converter)), // 6
-		PostalCodeShortNameSe:   strings.ToLower(BytesToString(src.PostalCodeShortNameSe[:], converter)), // 7
-		StreetNameFi:            strings.ToLower(BytesToString(src.StreetNameFi[:], converter)),          // 8
-		StreetNameSe:            strings.ToLower(BytesToString(src.StreetNameSe[:], converter)),          // 9
-		BuildingDataTypeEvenOdd: StringToEvenOddConst(BytesToString(src.BuildingDataType[:], converter)), // 12
-		SmallestBuilding:        smallest,                                                                // 14-18
-		HighestBuilding:         highest,                                                                 // 20-24
-		MunicipalityCode:        BytesToString(src.MunicipalityCode[:], converter),                       // 25
-		MunicipalityNameFi:      strings.ToLower(BytesToString(src.MunicipalityNameFi[:], converter)),    // 26
-		MunicipalityNameSe:      strings.ToLower(BytesToString(src.MunicipalityNameSe[:], converter)),    // 27
-	}
-
-	return p
+// Smallest and highest building number of record merged with range min..max, 0 and 0 if there are none
+// Unlike StreetNumberMinMax the minimum is the smallest number and not always 0.
+func (src StreetAddress) BuildingNumberRange(min int64, max int64) (int64, int64) {
+	numbers := []int64{src.SmallestBuilding.BuildingNumber1, src.SmallestBuilding.BuildingNumber2, src.HighestBuilding.BuildingNumber1, src.HighestBuilding.BuildingNumber2}
+
+	for _, n := range numbers {
+		if n <= 0 {
+			continue
+		}
+
+		if min == 0 || n < min {
+			min = n
+		}
+
+		if n > max {
+			max = n
+		}
+	}
+
+	return min, max
 }
 
-// Convert file to multiple JSON files
-func ConvertFile(sourcefile string, targetdir string) (err error) {
-	converter, err := iconv.NewConverter("iso-8859-1", "utf-8")
-	if err != nil {
-		return err
+// Conversion options
+type ConvertOptions struct {
+	Deterministic bool     // Sort entries and force file modes, same input gives byte-identical output
+	Pretty        bool     // Indent JSON
+	SortLanguage  string   // Language of names to sort by in deterministic output, "fi" or "sv" ("se" is a deprecated alias)
+	Layouts       []Layout // Output files, DefaultLayouts if empty
+	Incremental   bool     // Keep unchanged files of previous output with their modification times
+	MaxMemory     int64    // Convert in partitions spilled to disk to stay roughly under this many bytes, 0 converts in memory
+
+	Releases      bool          // Write to <targetdir>/<RunningDate> and keep a latest pointer
+	KeepReleases  int           // Prune releases beyond this count, 0 keeps all
+	MaxReleaseAge time.Duration // Prune releases with older running date, 0 keeps all
+
+	Filter Filter // Convert only matching records
+
+	Casing Casing        // Casing of names, LOWER if empty
+	Schema SchemaVersion // Output schema, V1 if empty
+
+	Generated time.Time // Generation time in meta.json, zero is current time or left out in deterministic output
+
+	Provenance bool // List source line ranges of contributing records in every entry
+
+	MaxShrink float64 // Refuse to publish when counts drop more than this many percent from previous output, 0 doesn't check
+}
+
+// Casing of names, LOWER if not set
+func (o ConvertOptions) casing() Casing {
+	if o.Casing == `` {
+		return LOWER
 	}
 
-	f, err := os.Open(sourcefile)
-	defer f.Close()
+	return o.Casing
+}
 
-	if err != nil {
-		return err
+// Generation time, current time if not set unless output is deterministic
+func (o ConvertOptions) generated() time.Time {
+	if o.Generated.IsZero() && !o.Deterministic {
+		return time.Now()
 	}
 
-	var raw RawLineStructure
+	return o.Generated
+}
+
+// Output schema, V1 if not set
+func (o ConvertOptions) schema() SchemaVersion {
+	if o.Schema == `` {
+		return V1
+	}
 
-	buffer := make([]byte, binary.Size(raw))
-	nl := make([]byte, 1) // new line
+	return o.Schema
+}
 
-	fInfo, err := f.Stat()
-	if err != nil {
-		return err
+// Converted source file
+type SourceInfo struct {
+	FileName       string `json:"file"`              // Source file name without directory
+	SHA256         string `json:"sha256"`            // Source file checksum
+	RunningDate    string `json:"runningdate"`       // Running date yyyymmdd
+	Records        int    `json:"records"`           // Number of converted lines
+	Skipped        int    `json:"skipped,omitempty"` // Number of lines skipped by filter
+	Municipalities int    `json:"municipalities"`    // Number of distinct municipality codes
+	PostalCodes    int    `json:"postalcodes"`       // Number of distinct postal codes
+	Streets        int    `json:"streets"`           // Number of distinct streets per postal code
+}
+
+// Counts distinct municipalities, postal codes and streets for SourceInfo
+type sourceCounter struct {
+	municipalities map[string]bool
+	postalCodes    map[string]bool
+	streets        map[string]bool
+}
+
+func newSourceCounter() *sourceCounter {
+	return &sourceCounter{
+		municipalities: make(map[string]bool),
+		postalCodes:    make(map[string]bool),
+		streets:        make(map[string]bool),
 	}
+}
 
-	var sourceTotalSizeBytes = fInfo.Size()
-	var sourceReadedBytes int64 = 0
+func (c *sourceCounter) Add(info *SourceInfo, addr StreetAddress) {
+	if info.RunningDate == `` {
+		info.RunningDate = addr.RunningDate
+	}
 
-	// Ticker for stats
-	ticker := time.NewTicker(time.Second * 1)
-	defer ticker.Stop()
+	info.Records++
+	c.municipalities[addr.MunicipalityCode] = true
+	c.postalCodes[addr.PostalCode] = true
+	if addr.StreetNameFi != `` {
+		c.streets[addr.PostalCode+`/`+addr.StreetNameFi] = true
+	}
+
+	info.Municipalities = len(c.municipalities)
+	info.PostalCodes = len(c.postalCodes)
+	info.Streets = len(c.streets)
+}
 
-	// Create new in-memory filesystem
-	fSystem := &afero.Afero{
-		Fs: afero.NewMemMapFs(),
+// Convert file to multiple JSON files
+// Conversion stops when ctx is cancelled
+func ConvertFile(ctx context.Context, sourcefile string, targetdir string, options ConvertOptions) (info SourceInfo, err error) {
+	layouts := options.Layouts
+	if len(layouts) == 0 {
+		layouts = DefaultLayouts
 	}
 
-	// Read source file line by line
-	for {
-		_, err := f.Read(buffer)
-		if err != nil {
-			if err == io.EOF {
-				break
-			} else {
-				return err
-			}
-		}
+	info.FileName = filepath.Base(sourcefile)
+	info.SHA256, err = FileSHA256(sourcefile)
+	if err != nil {
+		return info, err
+	}
 
-		// Read from file
-		r := bytes.NewReader(buffer)
+	var fSystem *afero.Afero
 
-		// Get position
-		pos, err := f.Seek(0, io.SeekCurrent)
+	if options.MaxMemory > 0 {
+		// Generated files are collected on disk
+		workdir, err := ioutil.TempDir(``, `FinnishStreetDatabaseConverter-`)
 		if err != nil {
-			return err
+			return info, err
 		}
+		defer os.RemoveAll(workdir)
 
-		sourceReadedBytes = pos
+		fSystem = &afero.Afero{
+			Fs: afero.NewBasePathFs(afero.NewOsFs(), workdir),
+		}
 
-		// Read to struct
-		err = binary.Read(r, binary.BigEndian, &raw)
+		err = ConvertPartitioned(ctx, sourcefile, fSystem, layouts, &info, options)
 		if err != nil {
-			return err
+			return info, err
 		}
+	} else {
+		// Create new in-memory filesystem
+		fSystem = &afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+
+		// Generated file paths and their content
+		kinds := make(map[string]OutputKind)
+		counter := newSourceCounter()
 
-		// Convert to proper struct
-		streetAddr := raw.ToStreet(converter)
+		err = ReadSourceFileCasing(ctx, sourcefile, options.casing(), func(streetAddr StreetAddress) error {
+			if !options.Filter.Match(streetAddr) {
+				info.Skipped++
+				return nil
+			}
+
+			counter.Add(&info, streetAddr)
+			return ConvertRecord(fSystem, layouts, kinds, options, streetAddr)
+		})
 
-		err = ConvertMunicipality(fSystem, streetAddr)
 		if err != nil {
-			panic(err)
+			return info, err
 		}
 
-		err = ConvertPostalCode(fSystem, streetAddr)
+		err = FinishFiles(fSystem, kinds, options)
 		if err != nil {
-			panic(err)
+			return info, err
 		}
+	}
 
-		err = ConvertStreet(fSystem, streetAddr)
+	// Indexes list the default directory tree
+	if HasDefaultLayouts(layouts) {
+		log.Printf(`Generating indexes..`)
+		indexes, err := GenerateIndexes(fSystem, options.schema())
 		if err != nil {
-			panic(err)
+			return info, err
 		}
 
-		// Report stats
-		select {
-		case <-ticker.C:
-			percent := (float64(sourceReadedBytes) * float64(100.0)) / float64(sourceTotalSizeBytes)
-			log.Printf("%v / %v %07.3f%%", sourceReadedBytes, sourceTotalSizeBytes, percent)
-			var m runtime.MemStats
-			runtime.ReadMemStats(&m)
-			log.Printf(`%v %v`, bytesToHuman(m.Alloc), bytesToHuman(m.TotalAlloc))
-		default:
-
+		if options.Pretty {
+			for _, fName := range indexes {
+				err = IndentFile(fSystem, fName)
+				if err != nil {
+					return info, err
+				}
+			}
 		}
+	}
 
-		_, err = f.Read(nl)
-
+	if options.schema() == V2 {
+		log.Printf(`Generating JSON schemas..`)
+		err = GenerateSchemas(fSystem, layouts, options.schema())
 		if err != nil {
-			if err == io.EOF {
-				break
-			} else {
-				return err
-			}
+			return info, err
 		}
+	}
+
+	log.Printf(`Generating metadata..`)
+	metadata, err := GenerateMetadata(fSystem, NewMetadata(info, options.generated()))
+	if err != nil {
+		return info, err
+	}
 
-		if nl[0] != '\n' {
-			return errors.New("Not newline")
+	if options.Pretty {
+		for _, fName := range metadata {
+			err = IndentFile(fSystem, fName)
+			if err != nil {
+				return info, err
+			}
 		}
+	}
 
+	log.Printf(`Generating manifest..`)
+	err = GenerateManifest(fSystem, info, options.schema())
+	if err != nil {
+		return info, err
 	}
 
-	log.Printf(`Generating directories..`)
-	// Create directories
-	err = fSystem.Walk(`/`, func(filepath string, info os.FileInfo, err error) error {
+	if options.MaxShrink > 0 {
+		err = checkPreviousOutput(targetdir, info, options)
 		if err != nil {
-			return err
-		}
-
-		if !info.IsDir() {
-			// Skip files
-			return nil
+			return info, err
 		}
+	}
 
-		dirPath := path.Join(targetdir, filepath)
+	if options.Releases {
+		return info, PublishRelease(fSystem, targetdir, info, options)
+	}
 
-		err = os.MkdirAll(dirPath, os.FileMode(0700))
-		if err != nil {
-			return err
-		}
+	return info, PublishFiles(fSystem, targetdir, options)
+}
 
-		return nil
-	})
+// Add street address to the files of every layout in options' schema
+// kinds records generated file paths and their content
+func ConvertRecord(fs *afero.Afero, layouts []Layout, kinds map[string]OutputKind, options ConvertOptions, streetAddr StreetAddress) error {
+	schema := options.schema()
 
-	if err != nil {
-		return err
+	// Converters list lines which are known
+	if !options.Provenance {
+		streetAddr.Line = 0
 	}
 
-	// Memory files to actual files
-	log.Printf(`Saving files..`)
-	err = fSystem.Walk(`/`, func(filepath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+	for _, layout := range layouts {
+		fName := layout.Path(streetAddr)
+
+		if kind, ok := kinds[fName]; ok && kind != layout.Kind {
+			return fmt.Errorf(`layouts %s and %s both generate file '%s'`, kind, layout.Kind, fName)
 		}
 
-		if info.IsDir() {
-			// Skip directories
-			return nil
+		// Street files of several postal codes keep their entries apart by postal code
+		postal := ``
+		if !layout.PerPostalCode() {
+			postal = streetAddr.PostalCode
+		}
+
+		var err error
+
+		switch {
+		case layout.Kind == STREET && streetAddr.StreetNameFi == ``:
+			continue
+		case schema == V2:
+			err = convertRecordV2(fs, layout.Kind, fName, postal, streetAddr)
+		case layout.Kind == MUNICIPALITY:
+			err = ConvertMunicipality(fs, fName, streetAddr)
+		case layout.Kind == POSTNUMBER:
+			err = ConvertPostalCode(fs, fName, streetAddr)
+		case layout.Kind == STREET:
+			err = ConvertStreet(fs, fName, postal, streetAddr)
 		}
 
-		b, err := fSystem.ReadFile(filepath)
 		if err != nil {
 			return err
 		}
 
-		dirPath := path.Join(targetdir, filepath)
-		err = ioutil.WriteFile(dirPath, b, os.FileMode(0600))
+		kinds[fName] = layout.Kind
+	}
+
+	return nil
+}
+
+// Sort and pretty-print generated files according to options
+func FinishFiles(fs *afero.Afero, kinds map[string]OutputKind, options ConvertOptions) (err error) {
+	if options.Deterministic {
+		err = SortFiles(fs, kinds, options.schema(), options.SortLanguage)
 		if err != nil {
 			return err
 		}
+	}
 
-		return nil
-	})
+	if options.Pretty {
+		for fName := range kinds {
+			err = IndentFile(fs, fName)
+			if err != nil {
+				return err
+			}
+		}
+	}
 
 	return nil
 }
 
 type PostnumberJSON struct {
-	Fi    string `json:"fi,omitempty"`  // Post number name in Finnish
-	Se    string `json:"se,omitempty"`  // Post number name in Swedish
-	FiLyh string `json:"fil,omitempty"` // Shortened post number name in Finnish
-	SeLyh string `json:"sel,omitempty"` // Shortened post number name in Swedish
+	Fi    string     `json:"fi,omitempty"`    // Post number name in Finnish
+	Se    string     `json:"se,omitempty"`    // Post number name in Swedish
+	FiLyh string     `json:"fil,omitempty"`   // Shortened post number name in Finnish
+	SeLyh string     `json:"sel,omitempty"`   // Shortened post number name in Swedish
+	Lines LineRanges `json:"lines,omitempty"` // Source lines, with provenance
 }
 
 func ConvertFromFile(fName string, fs *afero.Afero, v interface{}) error {
@@ -314,9 +423,7 @@ func ConvertFromFile(fName string, fs *afero.Afero, v interface{}) error {
 
 }
 
-func ConvertPostalCode(fs *afero.Afero, addr StreetAddress) error {
-	fName := path.Join(string(os.PathSeparator), addr.MunicipalityCode, addr.PostalCode, `postnumber.json`)
-
+func ConvertPostalCode(fs *afero.Afero, fName string, addr StreetAddress) error {
 	var data []PostnumberJSON
 
 	err := ConvertFromFile(fName, fs, &data)
@@ -327,6 +434,7 @@ func ConvertPostalCode(fs *afero.Afero, addr StreetAddress) error {
 	var found = false
 	for idx, k := range data {
 		if k.Fi == addr.PostalCodeNameFi {
+			k.Lines = k.Lines.Add(addr.Line)
 			data[idx] = k
 			found = true
 			break
@@ -339,6 +447,7 @@ func ConvertPostalCode(fs *afero.Afero, addr StreetAddress) error {
 			FiLyh: addr.PostalCodeShortNameFi,
 			Se:    addr.PostalCodeNameSe,
 			SeLyh: addr.PostalCodeShortNameSe,
+			Lines: LineRanges{}.Add(addr.Line),
 		})
 	}
 
@@ -366,13 +475,12 @@ func SaveData(fs *afero.Afero, s string, v interface{}) error {
 }
 
 type MunicipalityJSON struct {
-	Fi string `json:"fi,omitempty"` // Municipality name in Finnish
-	Se string `json:"se,omitempty"` // Municipality name in Swedish
+	Fi    string     `json:"fi,omitempty"`    // Municipality name in Finnish
+	Se    string     `json:"se,omitempty"`    // Municipality name in Swedish
+	Lines LineRanges `json:"lines,omitempty"` // Source lines, with provenance
 }
 
-func ConvertMunicipality(fs *afero.Afero, addr StreetAddress) error {
-	fName := path.Join(string(os.PathSeparator), addr.MunicipalityCode, `municipality.json`)
-
+func ConvertMunicipality(fs *afero.Afero, fName string, addr StreetAddress) error {
 	var data []MunicipalityJSON
 
 	err := ConvertFromFile(fName, fs, &data)
@@ -383,6 +491,7 @@ func ConvertMunicipality(fs *afero.Afero, addr StreetAddress) error {
 	var found = false
 	for idx, k := range data {
 		if k.Fi == addr.MunicipalityNameFi {
+			k.Lines = k.Lines.Add(addr.Line)
 			data[idx] = k
 			found = true
 			break
@@ -391,8 +500,9 @@ func ConvertMunicipality(fs *afero.Afero, addr StreetAddress) error {
 
 	if !found {
 		data = append(data, MunicipalityJSON{
-			Fi: addr.MunicipalityNameFi,
-			Se: addr.MunicipalityNameSe,
+			Fi:    addr.MunicipalityNameFi,
+			Se:    addr.MunicipalityNameSe,
+			Lines: LineRanges{}.Add(addr.Line),
 		})
 	}
 
@@ -405,19 +515,21 @@ func ConvertMunicipality(fs *afero.Afero, addr StreetAddress) error {
 }
 
 type StreetJSON struct {
-	Fi  string `json:"fi,omitempty"`  // Street name in Finnish
-	Se  string `json:"se,omitempty"`  // Street name in Swedish
-	Min int64  `json:"min,omitempty"` // Minimum number
-	Max int64  `json:"max,omitempty"` // Maximum number
+	PostalCode string     `json:"postal,omitempty"` // Postal code when the file has streets of several postal codes
+	Fi         string     `json:"fi,omitempty"`     // Street name in Finnish
+	Se         string     `json:"se,omitempty"`     // Street name in Swedish
+	Min        int64      `json:"min,omitempty"`    // Minimum number
+	Max        int64      `json:"max,omitempty"`    // Maximum number
+	Lines      LineRanges `json:"lines,omitempty"`  // Source lines, with provenance
 }
 
-func ConvertStreet(fs *afero.Afero, addr StreetAddress) error {
+// Add street to street file, entries are merged by postal code and Finnish name
+// postal is empty when the file has streets of one postal code only.
+func ConvertStreet(fs *afero.Afero, fName string, postal string, addr StreetAddress) error {
 	if addr.StreetNameFi == `` {
 		return nil
 	}
 
-	fName := path.Join(string(os.PathSeparator), addr.MunicipalityCode, addr.PostalCode, `street.json`)
-
 	var data []StreetJSON
 
 	err := ConvertFromFile(fName, fs, &data)
@@ -427,10 +539,11 @@ func ConvertStreet(fs *afero.Afero, addr StreetAddress) error {
 
 	var found = false
 	for idx, k := range data {
-		if k.Fi == addr.StreetNameFi {
+		if k.PostalCode == postal && k.Fi == addr.StreetNameFi {
 			min, max := addr.StreetNumberMinMax([]int64{k.Min, k.Max})
 			k.Min = min
 			k.Max = max
+			k.Lines = k.Lines.Add(addr.Line)
 			data[idx] = k
 			found = true
 			break
@@ -440,10 +553,12 @@ func ConvertStreet(fs *afero.Afero, addr StreetAddress) error {
 	if !found {
 		min, max := addr.StreetNumberMinMax([]int64{})
 		data = append(data, StreetJSON{
-			Fi:  addr.StreetNameFi,
-			Se:  addr.StreetNameSe,
-			Min: min,
-			Max: max,
+			PostalCode: postal,
+			Fi:         addr.StreetNameFi,
+			Se:         addr.StreetNameSe,
+			Min:        min,
+			Max:        max,
+			Lines:      LineRanges{}.Add(addr.Line),
 		})
 	}
 
@@ -454,3 +569,103 @@ func ConvertStreet(fs *afero.Afero, addr StreetAddress) error {
 
 	return nil
 }
+
+// Sort entries of generated files in schema alphabetically by names in given language ("fi" or "sv")
+func SortFiles(fs *afero.Afero, kinds map[string]OutputKind, schema SchemaVersion, lang string) (err error) {
+	for fName, kind := range kinds {
+		if schema == V2 {
+			err = sortFileV2(fs, fName, kind, lang)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		switch kind {
+		case MUNICIPALITY:
+			var data []MunicipalityJSON
+			err = ConvertFromFile(fName, fs, &data)
+			if err != nil {
+				return err
+			}
+
+			sort.SliceStable(data, func(i, j int) bool {
+				return lessNames(lang, data[i].Fi, data[i].Se, data[j].Fi, data[j].Se)
+			})
+
+			err = SaveData(fs, fName, data)
+
+		case POSTNUMBER:
+			var data []PostnumberJSON
+			err = ConvertFromFile(fName, fs, &data)
+			if err != nil {
+				return err
+			}
+
+			sort.SliceStable(data, func(i, j int) bool {
+				return lessNames(lang, data[i].Fi, data[i].Se, data[j].Fi, data[j].Se)
+			})
+
+			err = SaveData(fs, fName, data)
+
+		case STREET:
+			var data []StreetJSON
+			err = ConvertFromFile(fName, fs, &data)
+			if err != nil {
+				return err
+			}
+
+			sort.SliceStable(data, func(i, j int) bool {
+				if data[i].PostalCode != data[j].PostalCode {
+					return data[i].PostalCode < data[j].PostalCode
+				}
+
+				return lessNames(lang, data[i].Fi, data[i].Se, data[j].Fi, data[j].Se)
+			})
+
+			err = SaveData(fs, fName, data)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Pretty-print JSON file
+func IndentFile(fs *afero.Afero, fName string) error {
+	b, err := fs.ReadFile(fName)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	err = json.Indent(&out, b, ``, `  `)
+	if err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+
+	return fs.WriteFile(fName, out.Bytes(), os.FileMode(0600))
+}
+
+// Order by name in the sort language ("fi" or "sv", or deprecated "se") and then by name in the other language
+// Finnish names are compared with Finnish and Swedish names with Swedish collation
+func lessNames(lang string, aFi string, aSe string, bFi string, bSe string) bool {
+	if lang == `sv` || lang == `se` {
+		if cmp := SwedishCollator.Compare(aSe, bSe); cmp != 0 {
+			return cmp < 0
+		}
+
+		return FinnishCollator.Less(aFi, bFi)
+	}
+
+	if cmp := FinnishCollator.Compare(aFi, bFi); cmp != 0 {
+		return cmp < 0
+	}
+
+	return SwedishCollator.Less(aSe, bSe)
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"unicode/utf8"
+)
+
+// Byte range of a field in raw record
+type fieldRange struct {
+	name  string
+	no    int // Field number
+	start int
+	end   int
+}
+
+// Field range from RawLineStructure fixed tag
+func rawFieldRange(name string) fieldRange {
+	t := reflect.TypeOf(RawLineStructure{})
+
+	f, err := FixedFieldByName(t, name)
+	if err != nil {
+		panic(err)
+	}
+
+	// Records are also read into the struct with binary.Read, so the tags must match the array layout
+	sf, _ := t.FieldByName(name)
+	if int(sf.Offset) != f.Pos-1 {
+		panic(fmt.Sprintf(`RawLineStructure.%s: fixed tag pos=%d doesn't match struct offset %d`, name, f.Pos, sf.Offset+1))
+	}
+
+	return fieldRange{
+		name:  name,
+		no:    f.No,
+		start: f.Pos - 1,
+		end:   f.Pos - 1 + f.Len,
+	}
+}
+
+var (
+	rawRunningDate                     = rawFieldRange(`RunningDate`)
+	rawPostalCode                      = rawFieldRange(`PostalCode`)
+	rawPostalCodeNameFi                = rawFieldRange(`PostalCodeNameFi`)
+	rawPostalCodeNameSe                = rawFieldRange(`PostalCodeNameSe`)
+	rawPostalCodeShortNameFi           = rawFieldRange(`PostalCodeShortNameFi`)
+	rawPostalCodeShortNameSe           = rawFieldRange(`PostalCodeShortNameSe`)
+	rawStreetNameFi                    = rawFieldRange(`StreetNameFi`)
+	rawStreetNameSe                    = rawFieldRange(`StreetNameSe`)
+	rawBuildingDataType                = rawFieldRange(`BuildingDataType`)
+	rawSmallestBuildingNumber1         = rawFieldRange(`SmallestBuildingNumber1`)
+	rawSmallestBuildingDeliveryLetter1 = rawFieldRange(`SmallestBuildingDeliveryLetter1`)
+	rawSmallestPunctuationMark         = rawFieldRange(`SmallestPunctuationMark`)
+	rawSmallestBuildingNumber2         = rawFieldRange(`SmallestBuildingNumber2`)
+	rawSmallestBuildingDeliveryLetter2 = rawFieldRange(`SmallestBuildingDeliveryLetter2`)
+	rawHighestBuildingNumber1          = rawFieldRange(`HighestBuildingNumber1`)
+	rawHighestBuildingDeliveryLetter1  = rawFieldRange(`HighestBuildingDeliveryLetter1`)
+	rawHighestPunctuationMark          = rawFieldRange(`HighestPunctuationMark`)
+	rawHighestBuildingNumber2          = rawFieldRange(`HighestBuildingNumber2`)
+	rawHighestBuildingDeliveryLetter2  = rawFieldRange(`HighestBuildingDeliveryLetter2`)
+	rawMunicipalityCode                = rawFieldRange(`MunicipalityCode`)
+	rawMunicipalityNameFi              = rawFieldRange(`MunicipalityNameFi`)
+	rawMunicipalityNameSe              = rawFieldRange(`MunicipalityNameSe`)
+)
+
+// Record length without new line
+var rawRecordSize = rawLineSize()
+
+func rawLineSize() int {
+	fields, err := FixedLayout(reflect.TypeOf(RawLineStructure{}))
+	if err != nil {
+		panic(err)
+	}
+
+	return FixedRecordSize(fields)
+}
+
+// Decodes raw ISO-8859-1 records to StreetAddress
+// Fields are sliced straight from the record and converted to UTF-8 in a reused buffer.
+// Repeating values such as municipality and postal code names are interned, so a decoder
+// only allocates when it sees a value for the first time. Not safe for concurrent use.
+type RecordDecoder struct {
+	buf    []byte            // UTF-8 conversion buffer
+	intern map[string]string // Previously seen values
+	names  map[string]string // Previously seen names normalized and in casing
+	casing Casing            // Casing of names
+}
+
+// Decoder giving names in lower case
+func NewRecordDecoder() *RecordDecoder {
+	return NewRecordDecoderCasing(LOWER)
+}
+
+// Decoder giving names in casing
+func NewRecordDecoderCasing(casing Casing) *RecordDecoder {
+	return &RecordDecoder{
+		buf:    make([]byte, 0, 64),
+		intern: make(map[string]string),
+		names:  make(map[string]string),
+		casing: casing,
+	}
+}
+
+// Decode record without new line, in lower case gives the same result as the original iconv based conversion
+func (d *RecordDecoder) Decode(record []byte) (addr StreetAddress, err error) {
+	if len(record) != rawRecordSize {
+		return addr, fmt.Errorf(`invalid record length %d, expected %d`, len(record), rawRecordSize)
+	}
+
+	smallest := Building{
+		BuildingDeliveryLetter1: firstUTF8Byte(record, rawSmallestBuildingDeliveryLetter1), // 15
+		PunctuationMark:         firstUTF8Byte(record, rawSmallestPunctuationMark),         // 16
+		BuildingDeliveryLetter2: firstUTF8Byte(record, rawSmallestBuildingDeliveryLetter2), // 18
+	}
+
+	highest := Building{
+		BuildingDeliveryLetter1: firstUTF8Byte(record, rawHighestBuildingDeliveryLetter1), // 21
+		PunctuationMark:         firstUTF8Byte(record, rawHighestPunctuationMark),         // 22
+		BuildingDeliveryLetter2: firstUTF8Byte(record, rawHighestBuildingDeliveryLetter2), // 24
+	}
+
+	smallest.BuildingNumber1, err = parseNumber(record, rawSmallestBuildingNumber1) // 14
+	if err != nil {
+		return addr, err
+	}
+
+	smallest.BuildingNumber2, err = parseNumber(record, rawSmallestBuildingNumber2) // 17
+	if err != nil {
+		return addr, err
+	}
+
+	highest.BuildingNumber1, err = parseNumber(record, rawHighestBuildingNumber1) // 20
+	if err != nil {
+		return addr, err
+	}
+
+	highest.BuildingNumber2, err = parseNumber(record, rawHighestBuildingNumber2) // 23
+	if err != nil {
+		return addr, err
+	}
+
+	addr = StreetAddress{
+		RunningDate:             d.text(record, rawRunningDate, false),           // 2
+		PostalCode:              d.text(record, rawPostalCode, false),            // 3
+		PostalCodeNameFi:        d.text(record, rawPostalCodeNameFi, true),       // 4
+		PostalCodeNameSe:        d.text(record, rawPostalCodeNameSe, true),       // 5
+		PostalCodeShortNameFi:   d.text(record, rawPostalCodeShortNameFi, true),  // 6
+		PostalCodeShortNameSe:   d.text(record, rawPostalCodeShortNameSe, true),  // 7
+		StreetNameFi:            d.text(record, rawStreetNameFi, true),           // 8
+		StreetNameSe:            d.text(record, rawStreetNameSe, true),           // 9
+		BuildingDataTypeEvenOdd: evenOdd(trimField(record, rawBuildingDataType)), // 12
+		SmallestBuilding:        smallest,                                        // 14-18
+		HighestBuilding:         highest,                                         // 20-24
+		MunicipalityCode:        d.text(record, rawMunicipalityCode, false),      // 25
+		MunicipalityNameFi:      d.text(record, rawMunicipalityNameFi, true),     // 26
+		MunicipalityNameSe:      d.text(record, rawMunicipalityNameSe, true),     // 27
+	}
+
+	return addr, nil
+}
+
+// Field without surrounding white space
+func trimField(record []byte, f fieldRange) []byte {
+	b := record[f.start:f.end]
+
+	for len(b) > 0 && isASCIISpace(b[0]) {
+		b = b[1:]
+	}
+
+	for len(b) > 0 && isASCIISpace(b[len(b)-1]) {
+		b = b[:len(b)-1]
+	}
+
+	return b
+}
+
+func isASCIISpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\v' || c == '\f' || c == '\r'
+}
+
+// ISO-8859-1 field as interned UTF-8 string, name fields in NFC and decoder's casing
+func (d *RecordDecoder) text(record []byte, f fieldRange, name bool) string {
+	b := trimField(record, f)
+	if len(b) == 0 {
+		return ``
+	}
+
+	lower := name && d.casing != RAW
+
+	d.buf = d.buf[:0]
+
+	for _, c := range b {
+		if lower && latin1IsUpper(c) {
+			c += 'a' - 'A'
+		}
+
+		if c < utf8.RuneSelf {
+			d.buf = append(d.buf, c)
+		} else {
+			// ISO-8859-1 byte is the Unicode code point
+			d.buf = append(d.buf, 0xC0|c>>6, 0x80|c&0x3F)
+		}
+	}
+
+	intern := d.intern
+	if name {
+		intern = d.names
+	}
+
+	// Map lookup with converted []byte key doesn't allocate
+	if s, ok := intern[string(d.buf)]; ok {
+		return s
+	}
+
+	s := string(d.buf)
+	switch {
+	case name && d.casing == TITLE:
+		intern[s] = TitleCase(NormalizeName(s))
+	case name:
+		intern[s] = NormalizeName(s)
+	default:
+		intern[s] = s
+	}
+
+	return intern[s]
+}
+
+// A-Z and À-Þ except ×
+func latin1IsUpper(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 0xC0 && c <= 0xDE && c != 0xD7)
+}
+
+// First byte of the UTF-8 encoded field like in the original iconv based conversion
+func firstUTF8Byte(record []byte, f fieldRange) byte {
+	b := trimField(record, f)
+	if len(b) == 0 {
+		return 0
+	}
+
+	if b[0] < utf8.RuneSelf {
+		return b[0]
+	}
+
+	return 0xC0 | b[0]>>6
+}
+
+// Numeric field, empty field is -1 like in StringToInt64
+func parseNumber(record []byte, f fieldRange) (n int64, err error) {
+	b := trimField(record, f)
+	if len(b) == 0 {
+		return -1, nil
+	}
+
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, &FixedFieldError{f.name, f.no, f.start + 1, fmt.Errorf(`invalid number '%s'`, latin1ToUTF8(b))}
+		}
+
+		n = n*10 + int64(c-'0')
+	}
+
+	return n, nil
+}
+
+func evenOdd(b []byte) EvenOdd {
+	if len(b) == 1 {
+		switch b[0] {
+		case '1':
+			return ODD
+		case '2':
+			return EVEN
+		}
+	}
+
+	return NOTUSED
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/djimenez/iconv-go"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Original iconv based conversion, RecordDecoder must give the same result in lower case
+func (src *RawLineStructure) ToStreet(converter *iconv.Converter) StreetAddress {
+	smallest := Building{
+		BuildingNumber1:         StringToInt64(BytesToString(src.SmallestBuildingNumber1[:], converter)),        // 14
+		BuildingDeliveryLetter1: StringToByte(BytesToString(src.SmallestBuildingDeliveryLetter1[:], converter)), // 15
+		PunctuationMark:         StringToByte(BytesToString(src.SmallestPunctuationMark[:], converter)),         // 16
+		BuildingNumber2:         StringToInt64(BytesToString(src.SmallestBuildingNumber2[:], converter)),        // 17
+		BuildingDeliveryLetter2: StringToByte(BytesToString(src.SmallestBuildingDeliveryLetter2[:], converter)), // 18
+	}
+
+	highest := Building{
+		BuildingNumber1:         StringToInt64(BytesToString(src.HighestBuildingNumber1[:], converter)),        // 20
+		BuildingDeliveryLetter1: StringToByte(BytesToString(src.HighestBuildingDeliveryLetter1[:], converter)), // 21
+		PunctuationMark:         StringToByte(BytesToString(src.HighestPunctuationMark[:], converter)),         // 22
+		BuildingNumber2:         StringToInt64(BytesToString(src.HighestBuildingNumber2[:], converter)),        // 23
+		BuildingDeliveryLetter2: StringToByte(BytesToString(src.HighestBuildingDeliveryLetter2[:], converter)), // 24
+	}
+
+	p := StreetAddress{
+		RunningDate:             BytesToString(src.RunningDate[:], converter),                            // 2
+		PostalCode:              BytesToString(src.PostalCode[:], converter),                             // 3
+		PostalCodeNameFi:        strings.ToLower(BytesToString(src.PostalCodeNameFi[:], converter)),      // 4
+		PostalCodeNameSe:        strings.ToLower(BytesToString(src.PostalCodeNameSe[:], converter)),      // 5
+		PostalCodeShortNameFi:   strings.ToLower(BytesToString(src.PostalCodeShortNameFi[:], converter)), // 6
+		PostalCodeShortNameSe:   strings.ToLower(BytesToString(src.PostalCodeShortNameSe[:], converter)), // 7
+		StreetNameFi:            strings.ToLower(BytesToString(src.StreetNameFi[:], converter)),          // 8
+		StreetNameSe:            strings.ToLower(BytesToString(src.StreetNameSe[:], converter)),          // 9
+		BuildingDataTypeEvenOdd: StringToEvenOddConst(BytesToString(src.BuildingDataType[:], converter)), // 12
+		SmallestBuilding:        smallest,                                                                // 14-18
+		HighestBuilding:         highest,                                                                 // 20-24
+		MunicipalityCode:        BytesToString(src.MunicipalityCode[:], converter),                       // 25
+		MunicipalityNameFi:      strings.ToLower(BytesToString(src.MunicipalityNameFi[:], converter)),    // 26
+		MunicipalityNameSe:      strings.ToLower(BytesToString(src.MunicipalityNameSe[:], converter)),    // 27
+	}
+
+	return p
+}
+
+func BytesToString(bytes []byte, converter *iconv.Converter) string {
+	out, err := converter.ConvertString(strings.TrimSpace(string(bytes[:])))
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// Copy ISO-8859-1 value to field padded with spaces
+func setTestField(dst []byte, value string) {
+	n := copy(dst, value)
+	for i := n; i < len(dst); i++ {
+		dst[i] = ' '
+	}
+}
+
+// Generated raw records without new line
+// Names have ISO-8859-1 Ä, Å and Ö, and optional names, numbers and letters are left blank in some records.
+// Delivery letters are ASCII like in Posti's files, StreetAddress keeps only their first UTF-8 byte.
+func testRecords(n int) [][]byte {
+	postalNames := []string{"HELSINKI", "\xc5BO", "J\xc4RVENP\xc4\xc4", "\xd6ST\xc5NEBY"}
+	municipalities := []struct{ code, fi, se string }{
+		{"091", "HELSINKI", "HELSINGFORS"},
+		{"853", "TURKU", "\xc5BO"},
+		{"186", "J\xc4RVENP\xc4\xc4", ""},
+		{"478", "MAARIANHAMINA", "MARIEHAMN"},
+	}
+
+	records := make([][]byte, n)
+
+	for i := range records {
+		var raw RawLineStructure
+
+		m := municipalities[i%len(municipalities)]
+		postal := postalNames[i%len(postalNames)]
+
+		setTestField(raw.RecordIdentifier[:], "KATUN")
+		setTestField(raw.RunningDate[:], "20230101")
+		setTestField(raw.PostalCode[:], fmt.Sprintf("%05d", 100+i%50))
+		setTestField(raw.PostalCodeNameFi[:], postal)
+		setTestField(raw.PostalCodeShortNameFi[:], postal[:3])
+		setTestField(raw.StreetNameFi[:], fmt.Sprintf("\xc4IMI\xd6NKATU %d", i%200))
+		setTestField(raw.Blank1[:], "")
+		setTestField(raw.Blank2[:], "")
+		setTestField(raw.BuildingDataType[:], "")
+		setTestField(raw.SmallestBuildingNumber1[:], "")
+		setTestField(raw.SmallestBuildingDeliveryLetter1[:], "")
+		setTestField(raw.SmallestPunctuationMark[:], "")
+		setTestField(raw.SmallestBuildingNumber2[:], "")
+		setTestField(raw.SmallestBuildingDeliveryLetter2[:], "")
+		setTestField(raw.HighestBuildingNumber1[:], "")
+		setTestField(raw.HighestBuildingDeliveryLetter1[:], "")
+		setTestField(raw.HighestPunctuationMark[:], "")
+		setTestField(raw.HighestBuildingNumber2[:], "")
+		setTestField(raw.HighestBuildingDeliveryLetter2[:], "")
+		setTestField(raw.MunicipalityCode[:], m.code)
+		setTestField(raw.MunicipalityNameFi[:], m.fi)
+		setTestField(raw.MunicipalityNameSe[:], m.se)
+
+		if i%3 != 0 {
+			setTestField(raw.PostalCodeNameSe[:], postal+" SV")
+			setTestField(raw.PostalCodeShortNameSe[:], postal[:2])
+			setTestField(raw.StreetNameSe[:], fmt.Sprintf("\xc5GATAN %d", i%200))
+		}
+
+		if i%5 != 0 {
+			setTestField(raw.BuildingDataType[:], fmt.Sprint(1+i%2))
+			setTestField(raw.SmallestBuildingNumber1[:], fmt.Sprint(1+i%2))
+			setTestField(raw.HighestBuildingNumber1[:], fmt.Sprint(1+i%97))
+		}
+
+		if i%7 == 0 {
+			setTestField(raw.SmallestBuildingDeliveryLetter1[:], "A")
+			setTestField(raw.SmallestPunctuationMark[:], "-")
+			setTestField(raw.SmallestBuildingNumber2[:], "3")
+			setTestField(raw.HighestBuildingDeliveryLetter1[:], "B")
+			setTestField(raw.HighestPunctuationMark[:], "/")
+			setTestField(raw.HighestBuildingNumber2[:], fmt.Sprint(100+i%900))
+			setTestField(raw.HighestBuildingDeliveryLetter2[:], "C")
+		}
+
+		var buf bytes.Buffer
+		err := binary.Write(&buf, binary.LittleEndian, raw)
+		if err != nil {
+			panic(err)
+		}
+
+		records[i] = buf.Bytes()
+	}
+
+	return records
+}
+
+func newTestConverter(tb testing.TB) *iconv.Converter {
+	converter, err := iconv.NewConverter("iso-8859-1", "utf-8")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return converter
+}
+
+func TestDecodeMatchesToStreet(t *testing.T) {
+	converter := newTestConverter(t)
+	defer converter.Close()
+
+	decoder := NewRecordDecoder()
+
+	for idx, record := range testRecords(1000) {
+		var raw RawLineStructure
+		err := binary.Read(bytes.NewReader(record), binary.LittleEndian, &raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := raw.ToStreet(converter)
+
+		got, err := decoder.Decode(record)
+		if err != nil {
+			t.Fatalf(`record %d: %v`, idx, err)
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("record %d:\n got %+v\nwant %+v", idx, got, want)
+		}
+	}
+}
+
+func BenchmarkToStreet(b *testing.B) {
+	converter := newTestConverter(b)
+	defer converter.Close()
+
+	records := testRecords(1000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var raw RawLineStructure
+		err := binary.Read(bytes.NewReader(records[i%len(records)]), binary.LittleEndian, &raw)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		raw.ToStreet(converter)
+	}
+}
+
+func BenchmarkRecordDecoder(b *testing.B) {
+	decoder := NewRecordDecoder()
+	records := testRecords(1000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := decoder.Decode(records[i%len(records)])
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"sort"
+)
+
+type ChangeType string
+
+// Street change types
+const (
+	ADDED   ChangeType = "added"
+	REMOVED ChangeType = "removed"
+	RENAMED ChangeType = "renamed"
+)
+
+// Minimum confidence for pairing a removed and an added street as a rename
+const RenameMinConfidence = 0.6
+
+// Rename heuristic weights
+const (
+	renameWeightRange      = 0.4 // Overlap of building number ranges
+	renameWeightSwedish    = 0.3 // Matching Swedish names
+	renameWeightSimilarity = 0.3 // Finnish name similarity
+)
+
+type StreetChangeJSON struct {
+	Type       ChangeType  `json:"type"`
+	PostalCode string      `json:"postal"`
+	Old        *StreetJSON `json:"old,omitempty"`        // Street in previous release
+	New        *StreetJSON `json:"new,omitempty"`        // Street in new release
+	Confidence float64     `json:"confidence,omitempty"` // Rename confidence 0-1
+}
+
+// Streets per postal code, keyed by Finnish street name
+type streetsByPostalCode map[string]map[string]StreetJSON
+
+// Read streets from source file, aggregated the same way as in street.json
+func readStreets(sourcefile string) (streets streetsByPostalCode, err error) {
+	streets = make(streetsByPostalCode)
+
+	err = ReadSourceFile(sourcefile, func(addr StreetAddress) error {
+		if addr.StreetNameFi == `` {
+			return nil
+		}
+
+		if streets[addr.PostalCode] == nil {
+			streets[addr.PostalCode] = make(map[string]StreetJSON)
+		}
+
+		s := streets[addr.PostalCode][addr.StreetNameFi]
+		min, max := addr.BuildingNumberRange(s.Min, s.Max)
+
+		streets[addr.PostalCode][addr.StreetNameFi] = StreetJSON{
+			Fi:  addr.StreetNameFi,
+			Se:  addr.StreetNameSe,
+			Min: min,
+			Max: max,
+		}
+
+		return nil
+	})
+
+	return streets, err
+}
+
+// Compare two source files and list added, removed and renamed streets
+func DiffFiles(oldfile string, newfile string) (changes []StreetChangeJSON, err error) {
+	oldStreets, err := readStreets(oldfile)
+	if err != nil {
+		return nil, err
+	}
+
+	newStreets, err := readStreets(newfile)
+	if err != nil {
+		return nil, err
+	}
+
+	postalCodes := make(map[string]bool)
+	for postalCode := range oldStreets {
+		postalCodes[postalCode] = true
+	}
+	for postalCode := range newStreets {
+		postalCodes[postalCode] = true
+	}
+
+	changes = []StreetChangeJSON{}
+
+	for postalCode := range postalCodes {
+		var removed []StreetJSON
+		var added []StreetJSON
+
+		for name, s := range oldStreets[postalCode] {
+			if _, ok := newStreets[postalCode][name]; !ok {
+				removed = append(removed, s)
+			}
+		}
+
+		for name, s := range newStreets[postalCode] {
+			if _, ok := oldStreets[postalCode][name]; !ok {
+				added = append(added, s)
+			}
+		}
+
+		changes = append(changes, pairRenames(postalCode, removed, added)...)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].PostalCode != changes[j].PostalCode {
+			return changes[i].PostalCode < changes[j].PostalCode
+		}
+
+		return FinnishCollator.Less(changes[i].sortName(), changes[j].sortName())
+	})
+
+	return changes, nil
+}
+
+func (c StreetChangeJSON) sortName() string {
+	if c.Old != nil {
+		return c.Old.Fi
+	}
+
+	return c.New.Fi
+}
+
+// Pair removed and added streets within one postal code as renames, best matches first
+func pairRenames(postalCode string, removed []StreetJSON, added []StreetJSON) (changes []StreetChangeJSON) {
+	type candidate struct {
+		removedIdx int
+		addedIdx   int
+		confidence float64
+	}
+
+	var candidates []candidate
+
+	for ri, r := range removed {
+		for ai, a := range added {
+			confidence := RenameConfidence(r, a)
+			if confidence >= RenameMinConfidence {
+				candidates = append(candidates, candidate{ri, ai, confidence})
+			}
+		}
+	}
+
+	// Streets come from maps, equal confidences are ordered by names so that results don't vary between runs
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.confidence != b.confidence {
+			return a.confidence > b.confidence
+		}
+
+		if removed[a.removedIdx].Fi != removed[b.removedIdx].Fi {
+			return lessStreetName(removed[a.removedIdx].Fi, removed[b.removedIdx].Fi)
+		}
+
+		return lessStreetName(added[a.addedIdx].Fi, added[b.addedIdx].Fi)
+	})
+
+	removedUsed := make([]bool, len(removed))
+	addedUsed := make([]bool, len(added))
+
+	for _, c := range candidates {
+		if removedUsed[c.removedIdx] || addedUsed[c.addedIdx] {
+			continue
+		}
+
+		removedUsed[c.removedIdx] = true
+		addedUsed[c.addedIdx] = true
+
+		r := removed[c.removedIdx]
+		a := added[c.addedIdx]
+
+		changes = append(changes, StreetChangeJSON{
+			Type:       RENAMED,
+			PostalCode: postalCode,
+			Old:        &r,
+			New:        &a,
+			Confidence: c.confidence,
+		})
+	}
+
+	for idx := range removed {
+		if !removedUsed[idx] {
+			changes = append(changes, StreetChangeJSON{
+				Type:       REMOVED,
+				PostalCode: postalCode,
+				Old:        &removed[idx],
+			})
+		}
+	}
+
+	for idx := range added {
+		if !addedUsed[idx] {
+			changes = append(changes, StreetChangeJSON{
+				Type:       ADDED,
+				PostalCode: postalCode,
+				New:        &added[idx],
+			})
+		}
+	}
+
+	return changes
+}
+
+// Finnish collation order, names the collator sees as equal in byte order
+func lessStreetName(a string, b string) bool {
+	if cmp := FinnishCollator.Compare(a, b); cmp != 0 {
+		return cmp < 0
+	}
+
+	return a < b
+}
+
+// Overlap of building number ranges 0-1, overlapping numbers per numbers in either range
+func rangeOverlap(a StreetJSON, b StreetJSON) float64 {
+	lo, hi := Max(a.Min, b.Min), Min(a.Max, b.Max)
+	if lo > hi {
+		return 0
+	}
+
+	return float64(hi-lo+1) / float64(Max(a.Max, b.Max)-Min(a.Min, b.Min)+1)
+}
+
+// Score 0-1 how probable it is that street a was renamed to street b
+// Heuristics which have no data on either side (no building numbers, no Swedish name) are left out
+func RenameConfidence(a StreetJSON, b StreetJSON) float64 {
+	score := renameWeightSimilarity * StringSimilarity(a.Fi, b.Fi)
+	total := renameWeightSimilarity
+
+	if a.Max != 0 || b.Max != 0 {
+		total += renameWeightRange
+		if a.Max != 0 && b.Max != 0 {
+			score += renameWeightRange * rangeOverlap(a, b)
+		}
+	}
+
+	if a.Se != `` || b.Se != `` {
+		total += renameWeightSwedish
+		if a.Se == b.Se {
+			score += renameWeightSwedish
+		}
+	}
+
+	return score / total
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestRenameConfidenceRangeOverlap(t *testing.T) {
+	old := StreetJSON{Fi: `vanhakatu`, Min: 1, Max: 20}
+
+	same := RenameConfidence(old, StreetJSON{Fi: `uusikatu`, Min: 1, Max: 20})
+	half := RenameConfidence(old, StreetJSON{Fi: `uusikatu`, Min: 11, Max: 30})
+	none := RenameConfidence(old, StreetJSON{Fi: `uusikatu`, Min: 21, Max: 40})
+
+	if !(same > half && half > none) {
+		t.Fatalf(`confidence should fall with range overlap, got %v, %v, %v`, same, half, none)
+	}
+}
+
+func TestPairRenamesTieBreak(t *testing.T) {
+	removed := []StreetJSON{{Fi: `bkatu`, Max: 10}, {Fi: `akatu`, Max: 10}}
+	added := []StreetJSON{{Fi: `ckatu`, Max: 10}}
+
+	// Both removed streets match equally, the first in Finnish order wins whatever the slice order
+	for i := 0; i < 2; i++ {
+		changes := pairRenames(`00100`, removed, added)
+
+		if changes[0].Type != RENAMED || changes[0].Old.Fi != `akatu` {
+			t.Fatalf(`expected akatu renamed to ckatu, got %+v`, changes[0])
+		}
+
+		removed[0], removed[1] = removed[1], removed[0]
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+)
+
+// Options for extracting a subset of source file
+type ExtractOptions struct {
+	Filter     Filter
+	SampleRate float64 // Fraction of matching records to keep, 0 keeps all
+	Seed       int64   // Random seed for sampling, same seed gives the same sample
+}
+
+// Counts of extracted records
+type ExtractStats struct {
+	Records int64 // Records read
+	Matched int64 // Records written
+}
+
+// Read raw records of source file in order and call fn with 1-based line number and record without new line
+// The record slice is only valid until fn returns.
+func ReadRawRecords(ctx context.Context, r io.Reader, fn func(line int64, record []byte) error) error {
+	br := bufio.NewReaderSize(r, (rawRecordSize+1)*readChunkRecords)
+	buffer := make([]byte, rawRecordSize+1)
+
+	for line := int64(1); ; line++ {
+		if line%readChunkRecords == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		n, err := io.ReadFull(br, buffer)
+		if err == io.EOF {
+			return nil
+		}
+
+		// Last record may be missing its new line
+		if err == io.ErrUnexpectedEOF && n == rawRecordSize {
+			return fn(line, buffer[:rawRecordSize])
+		} else if err == io.ErrUnexpectedEOF {
+			return &SourceError{line, errors.New(`incomplete record`)}
+		} else if err != nil {
+			return err
+		}
+
+		if buffer[rawRecordSize] != '\n' {
+			return &SourceError{line, errors.New("Not newline")}
+		}
+
+		err = fn(line, buffer[:rawRecordSize])
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Write original lines of source file matching options to w, so the result is a valid smaller source file
+func ExtractFile(ctx context.Context, sourcefile string, w io.Writer, options ExtractOptions) (stats ExtractStats, err error) {
+	f, err := os.Open(sourcefile)
+	if err != nil {
+		return stats, err
+	}
+	defer f.Close()
+
+	bw := bufio.NewWriter(w)
+	decoder := NewRecordDecoder()
+	random := rand.New(rand.NewSource(options.Seed))
+
+	err = ReadRawRecords(ctx, f, func(line int64, record []byte) error {
+		stats.Records++
+
+		addr, err := decoder.Decode(record)
+		if err != nil {
+			return &SourceError{line, err}
+		}
+
+		if !options.Filter.Match(addr) {
+			return nil
+		}
+
+		// Sample is drawn from matching records only
+		if options.SampleRate > 0 && random.Float64() >= options.SampleRate {
+			return nil
+		}
+
+		stats.Matched++
+
+		_, err = bw.Write(record)
+		if err == nil {
+			err = bw.WriteByte('\n')
+		}
+		return err
+	})
+
+	if err != nil {
+		return stats, err
+	}
+
+	return stats, bw.Flush()
+}
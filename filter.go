@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Inclusive range of postal codes, From and To have the same length
+type PostalRange struct {
+	From string
+	To   string
+}
+
+// Parse postal code range "00100-00990", single postal code "00100" or prefix "001"
+// A prefix is the range of all postal codes starting with it, "001" is "00100-00199".
+func ParsePostalRange(s string) (r PostalRange, err error) {
+	parts := strings.SplitN(strings.TrimSpace(s), `-`, 2)
+
+	r.From = strings.TrimSpace(parts[0])
+	r.To = r.From
+	if len(parts) == 2 {
+		r.To = strings.TrimSpace(parts[1])
+	} else if len(r.From) > 0 && len(r.From) < 5 {
+		r.To = r.From + strings.Repeat(`9`, 5-len(r.From))
+		r.From += strings.Repeat(`0`, 5-len(r.From))
+	}
+
+	for _, code := range []string{r.From, r.To} {
+		if len(code) != 5 || strings.Trim(code, `0123456789`) != `` {
+			return r, fmt.Errorf(`invalid postal code '%s' in range '%s'`, code, s)
+		}
+	}
+
+	if r.From > r.To {
+		return r, fmt.Errorf(`invalid postal code range '%s', start is after end`, s)
+	}
+
+	return r, nil
+}
+
+func (r PostalRange) Contains(postalCode string) bool {
+	return postalCode >= r.From && postalCode <= r.To
+}
+
+// Selects street addresses, empty criteria match everything
+// A record matches when it matches every given criteria. Municipality codes and names
+// are one criteria, a record matches if it matches any of them.
+type Filter struct {
+	MunicipalityCodes []string       // Any of municipality codes
+	MunicipalityNames []string       // Any of Finnish or Swedish municipality names, case-insensitive
+	PostalRanges      []PostalRange  // Any of postal code ranges
+	BilingualOnly     bool           // Only streets with both Finnish and Swedish name
+	StreetPattern     *regexp.Regexp // Finnish or Swedish street name
+}
+
+// No criteria given
+func (f Filter) IsEmpty() bool {
+	return len(f.MunicipalityCodes) == 0 && len(f.MunicipalityNames) == 0 && len(f.PostalRanges) == 0 &&
+		!f.BilingualOnly && f.StreetPattern == nil
+}
+
+// Add municipality code, or name if s is not a number
+func (f *Filter) AddMunicipality(s string) {
+	s = strings.TrimSpace(s)
+
+	if s != `` && strings.Trim(s, `0123456789`) == `` {
+		f.MunicipalityCodes = append(f.MunicipalityCodes, s)
+	} else {
+		f.MunicipalityNames = append(f.MunicipalityNames, s)
+	}
+}
+
+func (f Filter) Match(addr StreetAddress) bool {
+	if len(f.MunicipalityCodes) > 0 || len(f.MunicipalityNames) > 0 {
+		found := false
+		for _, code := range f.MunicipalityCodes {
+			if addr.MunicipalityCode == code {
+				found = true
+				break
+			}
+		}
+
+		for _, name := range f.MunicipalityNames {
+			if found {
+				break
+			}
+
+			found = strings.EqualFold(addr.MunicipalityNameFi, name) || strings.EqualFold(addr.MunicipalityNameSe, name)
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.PostalRanges) > 0 {
+		found := false
+		for _, r := range f.PostalRanges {
+			if r.Contains(addr.PostalCode) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if f.BilingualOnly && (addr.StreetNameFi == `` || addr.StreetNameSe == ``) {
+		return false
+	}
+
+	if f.StreetPattern != nil {
+		if !f.StreetPattern.MatchString(addr.StreetNameFi) && !f.StreetPattern.MatchString(addr.StreetNameSe) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Fixed-width ISO-8859-1 record layouts defined with struct tags
+//
+//	Field [5]byte `fixed:"no=1,pos=1,len=5" doc:"Record identifier" example:"'KATUN'"`
+//
+// fixed tag options:
+//
+//	no=N      field number in file format description
+//	pos=N     1-based column of first byte
+//	len=N     length in bytes
+//	optional  field may be blank
+//	numeric   field contains only digits, or is blank if optional
+//	trim      surrounding spaces are removed when decoding to string
+//	right     value is right-aligned when encoding
+//
+// doc and example tags describe the field in the generated field table, 'quoted' example text is shown as code.
+// group tag "N:description" adds an unnumbered group header row before the field.
+// Field types can be [N]byte (raw bytes), string (UTF-8) or int64 (numeric, blank optional field is -1).
+
+// Field of fixed-width record
+type FixedField struct {
+	Name     string // Struct field name
+	index    int    // Struct field index
+	No       int    // Field number
+	Pos      int    // 1-based column
+	Len      int    // Length in bytes
+	Optional bool
+	Numeric  bool
+	Trim     bool
+	Right    bool
+	Doc      string
+	Example  string
+	GroupNo  int    // Group header number, 0 if none
+	GroupDoc string // Group header description
+}
+
+// Error in field of fixed-width record
+type FixedFieldError struct {
+	Field  string // Struct field name
+	No     int    // Field number
+	Column int    // 1-based column
+	Err    error
+}
+
+func (e *FixedFieldError) Error() string {
+	return fmt.Sprintf(`field #%d %s (column %d): %v`, e.No, e.Field, e.Column, e.Err)
+}
+
+var fixedLayouts sync.Map // reflect.Type -> []FixedField
+
+// Fields of fixed-width record struct type in column order
+func FixedLayout(t reflect.Type) (fields []FixedField, err error) {
+	if cached, ok := fixedLayouts.Load(t); ok {
+		return cached.([]FixedField), nil
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf(`fixed-width layout: %v is not a struct`, t)
+	}
+
+	end := 0
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		tag, ok := sf.Tag.Lookup(`fixed`)
+		if !ok {
+			continue
+		}
+
+		f := FixedField{
+			Name:    sf.Name,
+			index:   i,
+			Doc:     sf.Tag.Get(`doc`),
+			Example: sf.Tag.Get(`example`),
+		}
+
+		for _, opt := range strings.Split(tag, `,`) {
+			kv := strings.SplitN(opt, `=`, 2)
+
+			switch kv[0] {
+			case `no`, `pos`, `len`:
+				if len(kv) != 2 {
+					return nil, fmt.Errorf(`fixed-width layout %v.%s: %s needs a value`, t, sf.Name, kv[0])
+				}
+
+				n, err := strconv.Atoi(kv[1])
+				if err != nil {
+					return nil, fmt.Errorf(`fixed-width layout %v.%s: %v`, t, sf.Name, err)
+				}
+
+				switch kv[0] {
+				case `no`:
+					f.No = n
+				case `pos`:
+					f.Pos = n
+				case `len`:
+					f.Len = n
+				}
+			case `optional`:
+				f.Optional = true
+			case `numeric`:
+				f.Numeric = true
+			case `trim`:
+				f.Trim = true
+			case `right`:
+				f.Right = true
+			default:
+				return nil, fmt.Errorf(`fixed-width layout %v.%s: unknown option '%s'`, t, sf.Name, opt)
+			}
+		}
+
+		if group, ok := sf.Tag.Lookup(`group`); ok {
+			parts := strings.SplitN(group, `:`, 2)
+			f.GroupNo, err = strconv.Atoi(parts[0])
+			if err != nil || len(parts) != 2 {
+				return nil, fmt.Errorf(`fixed-width layout %v.%s: invalid group '%s'`, t, sf.Name, group)
+			}
+			f.GroupDoc = parts[1]
+		}
+
+		if f.Pos < 1 || f.Len < 1 {
+			return nil, fmt.Errorf(`fixed-width layout %v.%s: pos and len are required`, t, sf.Name)
+		}
+
+		if f.Pos-1 < end {
+			return nil, fmt.Errorf(`fixed-width layout %v.%s: column %d overlaps previous field`, t, sf.Name, f.Pos)
+		}
+		end = f.Pos - 1 + f.Len
+
+		switch sf.Type.Kind() {
+		case reflect.Array:
+			if sf.Type.Elem().Kind() != reflect.Uint8 || sf.Type.Len() != f.Len {
+				return nil, fmt.Errorf(`fixed-width layout %v.%s: expected [%d]byte`, t, sf.Name, f.Len)
+			}
+		case reflect.String:
+		case reflect.Int64:
+			if !f.Numeric {
+				return nil, fmt.Errorf(`fixed-width layout %v.%s: int64 field must be numeric`, t, sf.Name)
+			}
+		default:
+			return nil, fmt.Errorf(`fixed-width layout %v.%s: unsupported type %v`, t, sf.Name, sf.Type)
+		}
+
+		fields = append(fields, f)
+	}
+
+	fixedLayouts.Store(t, fields)
+
+	return fields, nil
+}
+
+// Record length of layout
+func FixedRecordSize(fields []FixedField) int {
+	if len(fields) == 0 {
+		return 0
+	}
+
+	last := fields[len(fields)-1]
+	return last.Pos - 1 + last.Len
+}
+
+// Look up field of layout by struct field name
+func FixedFieldByName(t reflect.Type, name string) (FixedField, error) {
+	fields, err := FixedLayout(t)
+	if err != nil {
+		return FixedField{}, err
+	}
+
+	for _, f := range fields {
+		if f.Name == name {
+			return f, nil
+		}
+	}
+
+	return FixedField{}, fmt.Errorf(`fixed-width layout %v: no field %s`, t, name)
+}
+
+// Decode fixed-width record without new line into struct pointed by v
+func UnmarshalFixed(record []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf(`fixed-width decode: expected pointer to struct, got %T`, v)
+	}
+	rv = rv.Elem()
+
+	fields, err := FixedLayout(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	if size := FixedRecordSize(fields); len(record) != size {
+		return fmt.Errorf(`fixed-width decode: invalid record length %d, expected %d`, len(record), size)
+	}
+
+	for _, f := range fields {
+		raw := record[f.Pos-1 : f.Pos-1+f.Len]
+		value := bytes.Trim(raw, ` `)
+
+		if f.Numeric {
+			if len(value) == 0 && !f.Optional {
+				return &FixedFieldError{f.Name, f.No, f.Pos, fmt.Errorf(`missing number`)}
+			}
+
+			for _, c := range value {
+				if c < '0' || c > '9' {
+					return &FixedFieldError{f.Name, f.No, f.Pos, fmt.Errorf(`invalid number '%s'`, latin1ToUTF8(value))}
+				}
+			}
+		}
+
+		fv := rv.Field(f.index)
+
+		switch fv.Kind() {
+		case reflect.Array:
+			reflect.Copy(fv, reflect.ValueOf(raw))
+		case reflect.String:
+			if f.Trim {
+				fv.SetString(latin1ToUTF8(value))
+			} else {
+				fv.SetString(latin1ToUTF8(raw))
+			}
+		case reflect.Int64:
+			if len(value) == 0 {
+				fv.SetInt(-1)
+				continue
+			}
+
+			n, err := strconv.ParseInt(string(value), 10, 64)
+			if err != nil {
+				return &FixedFieldError{f.Name, f.No, f.Pos, err}
+			}
+			fv.SetInt(n)
+		}
+	}
+
+	return nil
+}
+
+// Encode struct into fixed-width ISO-8859-1 record without new line
+// Values are padded with spaces, too long values and characters outside ISO-8859-1 are errors
+func MarshalFixed(v interface{}) ([]byte, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+
+	fields, err := FixedLayout(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	record := bytes.Repeat([]byte{' '}, FixedRecordSize(fields))
+
+	for _, f := range fields {
+		fv := rv.Field(f.index)
+
+		var value []byte
+
+		switch fv.Kind() {
+		case reflect.Array:
+			value = make([]byte, f.Len)
+			reflect.Copy(reflect.ValueOf(value), fv)
+		case reflect.String:
+			value, err = utf8ToLatin1(fv.String())
+			if err != nil {
+				return nil, &FixedFieldError{f.Name, f.No, f.Pos, err}
+			}
+		case reflect.Int64:
+			n := fv.Int()
+			if n == -1 && f.Optional {
+				break
+			}
+
+			if n < 0 {
+				return nil, &FixedFieldError{f.Name, f.No, f.Pos, fmt.Errorf(`negative number %d`, n)}
+			}
+
+			value = []byte(strconv.FormatInt(n, 10))
+		}
+
+		if len(value) > f.Len {
+			return nil, &FixedFieldError{f.Name, f.No, f.Pos, fmt.Errorf(`value '%s' longer than %d bytes`, latin1ToUTF8(value), f.Len)}
+		}
+
+		start := f.Pos - 1
+		if f.Right {
+			start += f.Len - len(value)
+		}
+
+		copy(record[start:], value)
+	}
+
+	return record, nil
+}
+
+// ISO-8859-1 bytes to UTF-8 string
+func latin1ToUTF8(b []byte) string {
+	var sb strings.Builder
+	sb.Grow(len(b))
+
+	for _, c := range b {
+		sb.WriteRune(rune(c))
+	}
+
+	return sb.String()
+}
+
+// UTF-8 string to ISO-8859-1 bytes
+func utf8ToLatin1(s string) ([]byte, error) {
+	b := make([]byte, 0, len(s))
+
+	for _, r := range s {
+		if r == utf8.RuneError || r > 0xFF {
+			return nil, fmt.Errorf(`character '%c' not in ISO-8859-1`, r)
+		}
+
+		b = append(b, byte(r))
+	}
+
+	return b, nil
+}
+
+var fixedExampleQuote = regexp.MustCompile(`'([^']*)'`)
+
+// Markdown table describing layout, the record is followed by a new line
+func FixedLayoutTable(t reflect.Type) (string, error) {
+	fields, err := FixedLayout(t)
+	if err != nil {
+		return ``, err
+	}
+
+	rows := [][]string{
+		{`#`, `Position`, `Length`, `Optional`, `Description`, `Example`},
+	}
+
+	lastNo := 0
+	for _, f := range fields {
+		if f.GroupNo != 0 {
+			rows = append(rows, []string{fmt.Sprintf(`%d.`, f.GroupNo), ``, ``, ``, f.GroupDoc, ``})
+		}
+
+		optional := ``
+		if f.Optional {
+			optional = `&#10004;`
+		}
+
+		rows = append(rows, []string{
+			fmt.Sprintf(`%d.`, f.No),
+			strconv.Itoa(f.Pos),
+			strconv.Itoa(f.Len),
+			optional,
+			f.Doc,
+			fixedExampleQuote.ReplaceAllString(f.Example, "\"`$1`\""),
+		})
+
+		lastNo = f.No
+	}
+
+	rows = append(rows, []string{
+		fmt.Sprintf(`%d.`, lastNo+1),
+		strconv.Itoa(FixedRecordSize(fields) + 1),
+		`1`,
+		``,
+		`New line`,
+		`"\n"`,
+	})
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			if l := utf8.RuneCountInString(cell); l > widths[i] {
+				widths[i] = l
+			}
+		}
+	}
+
+	var sb strings.Builder
+
+	writeRow := func(row []string, rightAlign bool) {
+		sb.WriteString(`|`)
+		for i, cell := range row {
+			pad := strings.Repeat(` `, widths[i]-utf8.RuneCountInString(cell))
+			// Numbers are right-aligned
+			if rightAlign && i < 3 {
+				sb.WriteString(` ` + pad + cell + ` |`)
+			} else {
+				sb.WriteString(` ` + cell + pad + ` |`)
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	writeRow(rows[0], false)
+
+	sb.WriteString(`|`)
+	for _, w := range widths {
+		sb.WriteString(strings.Repeat(`-`, w+2) + `|`)
+	}
+	sb.WriteString("\n")
+
+	for _, row := range rows[1:] {
+		writeRow(row, true)
+	}
+
+	return sb.String(), nil
+}
@@ -1,7 +1,9 @@
 module github.com/raspi/FinnishStreetDatabaseConverter
 
+go 1.27.1
+
 require (
 	github.com/djimenez/iconv-go v0.0.0-20160305225143-8960e66bd3da
 	github.com/spf13/afero v1.1.2
-	golang.org/x/text v0.3.0 // indirect
+	golang.org/x/text v0.3.0
 )
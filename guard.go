@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// Count dropped too much from previous output
+type ShrinkError struct {
+	Field    string  // Counted item
+	Previous int     // Count in previous output
+	Current  int     // Count in new conversion
+	Percent  float64 // Drop in percent
+}
+
+func (e *ShrinkError) Error() string {
+	return fmt.Sprintf(`%s dropped %.1f%% from %d to %d, refusing to publish`, e.Field, e.Percent, e.Previous, e.Current)
+}
+
+// Check that record, municipality and postal code counts didn't drop more than maxShrink percent
+func CheckShrink(previous SourceInfo, current SourceInfo, maxShrink float64) error {
+	counts := []struct {
+		field    string
+		previous int
+		current  int
+	}{
+		{`records`, previous.Records, current.Records},
+		{`municipalities`, previous.Municipalities, current.Municipalities},
+		{`postal codes`, previous.PostalCodes, current.PostalCodes},
+	}
+
+	for _, c := range counts {
+		if c.previous <= 0 || c.current >= c.previous {
+			continue
+		}
+
+		percent := float64(c.previous-c.current) * 100 / float64(c.previous)
+		if percent > maxShrink {
+			return &ShrinkError{c.field, c.previous, c.current, percent}
+		}
+	}
+
+	return nil
+}
+
+// Compare new conversion with the manifest of previously published output in targetdir
+// Nothing is checked when there's no previous output.
+func checkPreviousOutput(targetdir string, info SourceInfo, options ConvertOptions) error {
+	dir := targetdir
+	if options.Releases {
+		dir = filepath.Join(targetdir, LatestRelease)
+	}
+
+	previous, err := ReadManifest(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	log.Printf(`Comparing with previous output of '%s'..`, previous.Source.FileName)
+
+	return CheckShrink(previous.Source, info, options.MaxShrink)
+}
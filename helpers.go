@@ -1,10 +1,13 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"github.com/djimenez/iconv-go"
+	"io"
 	"math"
+	"os"
 	"strconv"
 	"strings"
 )
@@ -23,14 +26,6 @@ func StringToInt64(s string) int64 {
 
 }
 
-func BytesToString(bytes []byte, converter *iconv.Converter) string {
-	out, err := converter.ConvertString(strings.TrimSpace(string(bytes[:])))
-	if err != nil {
-		panic(err)
-	}
-	return out
-}
-
 func StringToByte(s string) byte {
 	if len(s) > 0 {
 		return []byte(s)[0]
@@ -125,3 +120,71 @@ func bytesToHuman(src uint64) string {
 
 	return fmt.Sprintf(f, val, suffix)
 }
+
+// Levenshtein edit distance between two strings, counted in runes
+func Levenshtein(a string, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			cur[j] = minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// String similarity 0-1 based on edit distance, 1 = identical
+func StringSimilarity(a string, b string) float64 {
+	length := len([]rune(a))
+	if l := len([]rune(b)); l > length {
+		length = l
+	}
+
+	if length == 0 {
+		return 1
+	}
+
+	return 1 - float64(Levenshtein(a, b))/float64(length)
+}
+
+func minInt(x, y int) int {
+	if x < y {
+		return x
+	}
+
+	return y
+}
+
+// Hex encoded SHA-256 checksum of a file
+func FileSHA256(fname string) (string, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return ``, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return ``, err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"github.com/spf13/afero"
+	"os"
+	"path"
+	"sort"
+)
+
+type MunicipalityIndexJSON struct {
+	Code        string `json:"code"`         // Municipality code
+	Fi          string `json:"fi,omitempty"` // Municipality name in Finnish
+	Se          string `json:"se,omitempty"` // Municipality name in Swedish
+	PostalCodes int    `json:"postalcodes"`  // Number of postal codes
+	Streets     int    `json:"streets"`      // Number of streets in all postal codes
+}
+
+type PostalCodeIndexJSON struct {
+	Code    string `json:"code"`         // Postal code
+	Fi      string `json:"fi,omitempty"` // Post number name in Finnish
+	Se      string `json:"se,omitempty"` // Post number name in Swedish
+	Streets int    `json:"streets"`      // Number of streets
+}
+
+// Generate /index.json listing municipalities and /<MunicipalityCode>/index.json listing postal codes
+// Names are read from and indexes written in schema
+// Returns paths of generated index files
+func GenerateIndexes(fs *afero.Afero, schema SchemaVersion) (files []string, err error) {
+	municipalityDirs, err := fs.ReadDir(`/`)
+	if err != nil {
+		return nil, err
+	}
+
+	municipalities := []MunicipalityIndexJSON{}
+
+	for _, municipalityDir := range municipalityDirs {
+		if !municipalityDir.IsDir() {
+			continue
+		}
+
+		municipalityPath := path.Join(string(os.PathSeparator), municipalityDir.Name())
+
+		// Skip directories of other layouts
+		exists, err := fs.Exists(path.Join(municipalityPath, `municipality.json`))
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			continue
+		}
+
+		m := MunicipalityIndexJSON{
+			Code: municipalityDir.Name(),
+		}
+
+		names, err := readMunicipalityNames(fs, path.Join(municipalityPath, `municipality.json`), schema)
+		if err != nil {
+			return nil, err
+		}
+
+		m.Fi = names.Fi
+		m.Se = names.Se
+
+		postalCodes, err := indexPostalCodes(fs, municipalityPath, schema)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range postalCodes {
+			m.Streets += p.Streets
+		}
+		m.PostalCodes = len(postalCodes)
+
+		var postalCodesData interface{} = postalCodes
+		if schema == V2 {
+			v2 := []PostalCodeIndexV2JSON{}
+			for _, p := range postalCodes {
+				v2 = append(v2, p.v2())
+			}
+			postalCodesData = v2
+		}
+
+		fName := path.Join(municipalityPath, `index.json`)
+		err = SaveData(fs, fName, postalCodesData)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, fName)
+
+		municipalities = append(municipalities, m)
+	}
+
+	sort.Slice(municipalities, func(i, j int) bool {
+		return municipalities[i].Code < municipalities[j].Code
+	})
+
+	var municipalitiesData interface{} = municipalities
+	if schema == V2 {
+		v2 := []MunicipalityIndexV2JSON{}
+		for _, m := range municipalities {
+			v2 = append(v2, m.v2())
+		}
+		municipalitiesData = v2
+	}
+
+	fName := path.Join(string(os.PathSeparator), `index.json`)
+	err = SaveData(fs, fName, municipalitiesData)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(files, fName), nil
+}
+
+// List postal codes of one municipality directory
+func indexPostalCodes(fs *afero.Afero, municipalityPath string, schema SchemaVersion) (postalCodes []PostalCodeIndexJSON, err error) {
+	postalCodeDirs, err := fs.ReadDir(municipalityPath)
+	if err != nil {
+		return nil, err
+	}
+
+	postalCodes = []PostalCodeIndexJSON{}
+
+	for _, postalCodeDir := range postalCodeDirs {
+		if !postalCodeDir.IsDir() {
+			continue
+		}
+
+		postalCodePath := path.Join(municipalityPath, postalCodeDir.Name())
+
+		exists, err := fs.Exists(path.Join(postalCodePath, `postnumber.json`))
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			continue
+		}
+
+		p := PostalCodeIndexJSON{
+			Code: postalCodeDir.Name(),
+		}
+
+		names, err := readPostalCodeNames(fs, path.Join(postalCodePath, `postnumber.json`), schema)
+		if err != nil {
+			return nil, err
+		}
+
+		p.Fi = names.Fi
+		p.Se = names.Se
+
+		// Postal codes without any street names have no street.json
+		streetsPath := path.Join(postalCodePath, `street.json`)
+		exists, err = fs.Exists(streetsPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if exists {
+			var streets []StreetJSON
+			err = ConvertFromFile(streetsPath, fs, &streets)
+			if err != nil {
+				return nil, err
+			}
+
+			p.Streets = len(streets)
+		}
+
+		postalCodes = append(postalCodes, p)
+	}
+
+	sort.Slice(postalCodes, func(i, j int) bool {
+		return postalCodes[i].Code < postalCodes[j].Code
+	})
+
+	return postalCodes, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/afero"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+)
+
+// Directory of JSON Schema documents in schema v2 output, /schema/{file type}.schema.json
+const SchemaDir = `schema`
+
+const jsonSchemaDraft = `https://json-schema.org/draft/2020-12/schema`
+
+// Output file types with a JSON Schema document
+var SchemaFileTypes = []string{`municipality`, `postnumber`, `street`, `index`, `postalindex`, `manifest`, `meta`}
+
+var schemaFileDescriptions = map[string]string{
+	`municipality`: `Municipality names, /{municipality}/municipality.json`,
+	`postnumber`:   `Postal code names, /{municipality}/{postal}/postnumber.json`,
+	`street`:       `Streets and building number ranges, /{municipality}/{postal}/street.json`,
+	`index`:        `Municipalities, /index.json`,
+	`postalindex`:  `Postal codes of a municipality, /{municipality}/index.json`,
+	`manifest`:     `Source file and checksums of generated files, /manifest.json`,
+	`meta`:         `Source release and generation of output, meta.json in every directory`,
+}
+
+// Go value of each file type, documents are generated from the types
+var schemaFileValues = map[SchemaVersion]map[string]interface{}{
+	V1: {
+		`municipality`: []MunicipalityJSON{},
+		`postnumber`:   []PostnumberJSON{},
+		`street`:       []StreetJSON{},
+		`index`:        []MunicipalityIndexJSON{},
+		`postalindex`:  []PostalCodeIndexJSON{},
+		`manifest`:     ManifestJSON{},
+		`meta`:         MetadataJSON{},
+	},
+	V2: {
+		`municipality`: MunicipalityV2JSON{},
+		`postnumber`:   []PostnumberV2JSON{},
+		`street`:       []StreetV2JSON{},
+		`index`:        []MunicipalityIndexV2JSON{},
+		`postalindex`:  []PostalCodeIndexV2JSON{},
+		`manifest`:     ManifestJSON{},
+		`meta`:         MetadataJSON{},
+	},
+}
+
+// JSON Schema document of output file type in schema version
+func JSONSchema(fileType string, version SchemaVersion) (doc map[string]interface{}, err error) {
+	v, ok := schemaFileValues[version][fileType]
+	if !ok {
+		return nil, fmt.Errorf(`unknown file type '%s', expected %s`, fileType, strings.Join(SchemaFileTypes, `, `))
+	}
+
+	doc = jsonSchemaOf(reflect.TypeOf(v))
+	doc[`$schema`] = jsonSchemaDraft
+	doc[`title`] = fmt.Sprintf(`%s %s`, fileType, version)
+	doc[`description`] = schemaFileDescriptions[fileType]
+
+	return doc, nil
+}
+
+// Schema of Go type from its JSON encoding, fields without omitempty are required
+func jsonSchemaOf(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{`type`: `string`}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{`type`: `integer`}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{`type`: `number`}
+	case reflect.Bool:
+		return map[string]interface{}{`type`: `boolean`}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{`type`: `array`, `items`: jsonSchemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{`type`: `object`, `additionalProperties`: jsonSchemaOf(t.Elem())}
+	case reflect.Ptr:
+		return jsonSchemaOf(t.Elem())
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		required := []string{}
+		jsonSchemaFields(t, properties, &required)
+
+		return map[string]interface{}{
+			`type`:                 `object`,
+			`properties`:           properties,
+			`required`:             required,
+			`additionalProperties`: false,
+		}
+	}
+
+	return map[string]interface{}{}
+}
+
+// Add properties of struct fields, fields of embedded structs are promoted like in encoding/json
+func jsonSchemaFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get(`json`)
+		if tag == `-` {
+			continue
+		}
+
+		if f.Anonymous && tag == `` && f.Type.Kind() == reflect.Struct {
+			jsonSchemaFields(f.Type, properties, required)
+			continue
+		}
+
+		if f.PkgPath != `` {
+			continue
+		}
+
+		name, options := f.Name, ``
+		if tag != `` {
+			parts := strings.SplitN(tag, `,`, 2)
+			if parts[0] != `` {
+				name = parts[0]
+			}
+
+			if len(parts) == 2 {
+				options = parts[1]
+			}
+		}
+
+		properties[name] = jsonSchemaOf(f.Type)
+
+		if !strings.Contains(options, `omitempty`) {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// Write /schema/{file type}.schema.json for generated file types
+func GenerateSchemas(fs *afero.Afero, layouts []Layout, version SchemaVersion) error {
+	fileTypes := []string{}
+	for _, layout := range layouts {
+		fileTypes = append(fileTypes, string(layout.Kind))
+	}
+
+	if HasDefaultLayouts(layouts) {
+		fileTypes = append(fileTypes, `index`, `postalindex`)
+	}
+
+	fileTypes = append(fileTypes, `manifest`, `meta`)
+
+	err := fs.MkdirAll(path.Join(`/`, SchemaDir), os.FileMode(0700))
+	if err != nil {
+		return err
+	}
+
+	for _, fileType := range fileTypes {
+		doc, err := JSONSchema(fileType, version)
+		if err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(doc, ``, `  `)
+		if err != nil {
+			return err
+		}
+
+		// Layouts of the same kind write the same document
+		err = fs.WriteFile(path.Join(`/`, SchemaDir, fileType+`.schema.json`), append(b, '\n'), os.FileMode(0600))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
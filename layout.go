@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+type OutputKind string // Content of an output file
+
+// Output file kinds
+const (
+	MUNICIPALITY OutputKind = "municipality" // Municipality names, MunicipalityJSON
+	POSTNUMBER   OutputKind = "postnumber"   // Postal code names, PostnumberJSON
+	STREET       OutputKind = "street"       // Streets, StreetJSON
+)
+
+// Output file path template
+// Placeholders {municipality} and {postal} are replaced with municipality code and postal code.
+// Records which produce the same path are merged into the same file, so for example
+// leaving {municipality} out of a postal code template gives a flat postal code lookup.
+type Layout struct {
+	Kind     OutputKind
+	Template string
+}
+
+// Directory layout /<MunicipalityCode>/<PostalCode>/street.json
+var DefaultLayouts = []Layout{
+	{MUNICIPALITY, `/{municipality}/municipality.json`},
+	{POSTNUMBER, `/{municipality}/{postal}/postnumber.json`},
+	{STREET, `/{municipality}/{postal}/street.json`},
+}
+
+var layoutPlaceholder = regexp.MustCompile(`{[^}]*}`)
+
+// Parse "<kind>=<template>", for example "street=postal/{postal}.json"
+// "default" gives DefaultLayouts
+func ParseLayout(s string) (layouts []Layout, err error) {
+	if s == `default` {
+		return DefaultLayouts, nil
+	}
+
+	parts := strings.SplitN(s, `=`, 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf(`invalid layout '%s', expected <kind>=<template>`, s)
+	}
+
+	l := Layout{
+		Kind:     OutputKind(parts[0]),
+		Template: path.Join(string(os.PathSeparator), parts[1]),
+	}
+
+	switch l.Kind {
+	case MUNICIPALITY, POSTNUMBER, STREET:
+	default:
+		return nil, fmt.Errorf(`invalid layout '%s', unknown kind '%s'`, s, parts[0])
+	}
+
+	for _, placeholder := range layoutPlaceholder.FindAllString(l.Template, -1) {
+		switch placeholder {
+		case `{municipality}`, `{postal}`:
+		default:
+			return nil, fmt.Errorf(`invalid layout '%s', unknown placeholder %s`, s, placeholder)
+		}
+	}
+
+	if strings.HasSuffix(parts[1], `/`) || path.Base(l.Template) == string(os.PathSeparator) {
+		return nil, fmt.Errorf(`invalid layout '%s', template must name a file`, s)
+	}
+
+	return []Layout{l}, nil
+}
+
+// File path for street address
+func (l Layout) Path(addr StreetAddress) string {
+	r := strings.NewReplacer(
+		`{municipality}`, addr.MunicipalityCode,
+		`{postal}`, addr.PostalCode,
+	)
+
+	return r.Replace(l.Template)
+}
+
+// Every file of layout has one postal code
+func (l Layout) PerPostalCode() bool {
+	return strings.Contains(l.Template, `{postal}`)
+}
+
+// Are all default layouts included
+func HasDefaultLayouts(layouts []Layout) bool {
+	for _, d := range DefaultLayouts {
+		found := false
+		for _, l := range layouts {
+			if l == d {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+type Severity string
+
+// Lint finding severities
+const (
+	INFO    Severity = "info"
+	WARNING Severity = "warning"
+	ERROR   Severity = "error"
+)
+
+var severityRank = map[Severity]int{
+	INFO:    1,
+	WARNING: 2,
+	ERROR:   3,
+}
+
+// Parse severity name
+func ParseSeverity(s string) (Severity, error) {
+	if _, ok := severityRank[Severity(s)]; !ok {
+		return ``, fmt.Errorf(`unknown severity '%s', expected info, warning or error`, s)
+	}
+
+	return Severity(s), nil
+}
+
+// Severity is at least min
+func (s Severity) AtLeast(min Severity) bool {
+	return severityRank[s] >= severityRank[min]
+}
+
+type LintRule string
+
+// Lint rules
+const (
+	DECODE           LintRule = "decode"           // Record can't be decoded
+	CONTROLCHAR      LintRule = "controlchar"      // Control character in record
+	BUILDINGRANGE    LintRule = "buildingrange"    // Smallest building after highest or building number 2 before number 1
+	PARITY           LintRule = "parity"           // Building data type doesn't match parity of numbers
+	SWEDISHNAME      LintRule = "swedishname"      // Street has different Swedish names
+	MUNICIPALITYNAME LintRule = "municipalityname" // Municipality code has different names
+	ABBREVIATION     LintRule = "abbreviation"     // Postal code name abbreviation doesn't abbreviate the name
+)
+
+// Default severity of rules
+var LintRules = map[LintRule]Severity{
+	DECODE:           ERROR,
+	CONTROLCHAR:      ERROR,
+	BUILDINGRANGE:    ERROR,
+	PARITY:           WARNING,
+	SWEDISHNAME:      WARNING,
+	MUNICIPALITYNAME: ERROR,
+	ABBREVIATION:     INFO,
+}
+
+type LintFindingJSON struct {
+	Line         int64    `json:"line"` // 1-based line number
+	Rule         LintRule `json:"rule"`
+	Severity     Severity `json:"severity"`
+	Message      string   `json:"message"`
+	PostalCode   string   `json:"postal,omitempty"`
+	Municipality string   `json:"municipality,omitempty"` // Municipality code
+	Street       string   `json:"street,omitempty"`       // Street name in Finnish
+}
+
+// Suppresses findings matching every given field
+type LintIgnoreJSON struct {
+	Rule         LintRule `json:"rule,omitempty"`
+	Line         int64    `json:"line,omitempty"`
+	PostalCode   string   `json:"postal,omitempty"`
+	Municipality string   `json:"municipality,omitempty"`
+	Street       string   `json:"street,omitempty"` // Case-insensitive
+}
+
+func (i LintIgnoreJSON) Match(f LintFindingJSON) bool {
+	return (i.Rule == `` || i.Rule == f.Rule) &&
+		(i.Line == 0 || i.Line == f.Line) &&
+		(i.PostalCode == `` || i.PostalCode == f.PostalCode) &&
+		(i.Municipality == `` || i.Municipality == f.Municipality) &&
+		(i.Street == `` || strings.EqualFold(i.Street, f.Street))
+}
+
+// Lint configuration file
+//
+//	{
+//	  "disable": ["abbreviation"],
+//	  "severity": {"parity": "info"},
+//	  "ignore": [{"rule": "swedishname", "postal": "00100", "street": "mannerheimintie"}, {"line": 1234}]
+//	}
+type LintConfigJSON struct {
+	Disable  []LintRule            `json:"disable,omitempty"`  // Rules not checked
+	Severity map[LintRule]Severity `json:"severity,omitempty"` // Severity overrides
+	Ignore   []LintIgnoreJSON      `json:"ignore,omitempty"`   // Suppressed findings
+}
+
+// Read and check lint configuration file
+func ReadLintConfig(fname string) (config LintConfigJSON, err error) {
+	b, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return config, err
+	}
+
+	err = json.Unmarshal(b, &config)
+	if err != nil {
+		return config, fmt.Errorf(`%s: %v`, fname, err)
+	}
+
+	for _, rule := range config.Disable {
+		if _, ok := LintRules[rule]; !ok {
+			return config, fmt.Errorf(`%s: unknown rule '%s'`, fname, rule)
+		}
+	}
+
+	for rule, severity := range config.Severity {
+		if _, ok := LintRules[rule]; !ok {
+			return config, fmt.Errorf(`%s: unknown rule '%s'`, fname, rule)
+		}
+
+		_, err = ParseSeverity(string(severity))
+		if err != nil {
+			return config, fmt.Errorf(`%s: %v`, fname, err)
+		}
+	}
+
+	for _, ignore := range config.Ignore {
+		if ignore == (LintIgnoreJSON{}) {
+			return config, fmt.Errorf(`%s: empty ignore entry would suppress everything`, fname)
+		}
+
+		if _, ok := LintRules[ignore.Rule]; ignore.Rule != `` && !ok {
+			return config, fmt.Errorf(`%s: unknown rule '%s'`, fname, ignore.Rule)
+		}
+	}
+
+	return config, nil
+}
+
+// Name seen first on a line
+type lintName struct {
+	fi   string
+	se   string
+	line int64
+}
+
+type linter struct {
+	config   LintConfigJSON
+	disabled map[LintRule]bool
+	findings []LintFindingJSON
+
+	swedishNames      map[string]lintName // Postal code and Finnish street name
+	municipalityNames map[string]lintName // Municipality code
+	reported          map[string]bool     // Findings reported once per value
+}
+
+func newLinter(config LintConfigJSON) *linter {
+	l := &linter{
+		config:            config,
+		disabled:          make(map[LintRule]bool),
+		swedishNames:      make(map[string]lintName),
+		municipalityNames: make(map[string]lintName),
+		reported:          make(map[string]bool),
+	}
+
+	for _, rule := range config.Disable {
+		l.disabled[rule] = true
+	}
+
+	return l
+}
+
+func (l *linter) report(finding LintFindingJSON) {
+	if l.disabled[finding.Rule] {
+		return
+	}
+
+	finding.Severity = LintRules[finding.Rule]
+	if severity, ok := l.config.Severity[finding.Rule]; ok {
+		finding.Severity = severity
+	}
+
+	for _, ignore := range l.config.Ignore {
+		if ignore.Match(finding) {
+			return
+		}
+	}
+
+	l.findings = append(l.findings, finding)
+}
+
+// Report finding only the first time key is seen
+func (l *linter) reportOnce(key string, finding LintFindingJSON) {
+	key = string(finding.Rule) + `/` + key
+	if l.reported[key] {
+		return
+	}
+
+	l.reported[key] = true
+	l.report(finding)
+}
+
+// Check source file and list findings in line order
+// Structural errors such as an incomplete record stop checking and are returned as error.
+func LintFile(ctx context.Context, sourcefile string, config LintConfigJSON) (findings []LintFindingJSON, err error) {
+	f, err := os.Open(sourcefile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	l := newLinter(config)
+	decoder := NewRecordDecoder()
+
+	err = ReadRawRecords(ctx, f, func(line int64, record []byte) error {
+		l.checkControlChars(line, record)
+
+		addr, err := decoder.Decode(record)
+		if err != nil {
+			var fieldErr *FixedFieldError
+			if !errors.As(err, &fieldErr) {
+				return &SourceError{line, err}
+			}
+
+			l.report(LintFindingJSON{
+				Line:    line,
+				Rule:    DECODE,
+				Message: err.Error(),
+			})
+			return nil
+		}
+
+		l.checkRecord(line, addr)
+		return nil
+	})
+
+	return l.findings, err
+}
+
+func (l *linter) checkControlChars(line int64, record []byte) {
+	for i, c := range record {
+		if c < 0x20 || (c >= 0x7F && c < 0xA0) {
+			l.report(LintFindingJSON{
+				Line:    line,
+				Rule:    CONTROLCHAR,
+				Message: fmt.Sprintf(`control character 0x%02X at column %d`, c, i+1),
+			})
+			return
+		}
+	}
+}
+
+func (l *linter) checkRecord(line int64, addr StreetAddress) {
+	finding := func(rule LintRule, format string, a ...interface{}) LintFindingJSON {
+		return LintFindingJSON{
+			Line:         line,
+			Rule:         rule,
+			Message:      fmt.Sprintf(format, a...),
+			PostalCode:   addr.PostalCode,
+			Municipality: addr.MunicipalityCode,
+			Street:       addr.StreetNameFi,
+		}
+	}
+
+	smallest, highest := addr.SmallestBuilding, addr.HighestBuilding
+
+	for _, b := range []Building{smallest, highest} {
+		if b.BuildingNumber1 >= 0 && b.BuildingNumber2 >= 0 && b.BuildingNumber1 > b.BuildingNumber2 {
+			l.report(finding(BUILDINGRANGE, `building number %d-%d ends before it starts`, b.BuildingNumber1, b.BuildingNumber2))
+		}
+	}
+
+	// Building number 2 ends a building such as 12-14, compare starts and ends separately
+	if smallest.BuildingNumber1 >= 0 && highest.BuildingNumber1 >= 0 && smallest.BuildingNumber1 > highest.BuildingNumber1 {
+		l.report(finding(BUILDINGRANGE, `smallest building number %d is greater than highest %d`, smallest.BuildingNumber1, highest.BuildingNumber1))
+	} else if end, last := buildingEnd(smallest), buildingEnd(highest); end >= 0 && last >= 0 && end > last {
+		l.report(finding(BUILDINGRANGE, `smallest building ends at %d after highest building ends at %d`, end, last))
+	}
+
+	if addr.BuildingDataTypeEvenOdd != NOTUSED {
+		for _, n := range []int64{smallest.BuildingNumber1, smallest.BuildingNumber2, highest.BuildingNumber1, highest.BuildingNumber2} {
+			if n < 0 {
+				continue
+			}
+
+			if (n%2 == 1) != (addr.BuildingDataTypeEvenOdd == ODD) {
+				l.report(finding(PARITY, `building number %d doesn't match building data type %s`, n, evenOddToString(addr.BuildingDataTypeEvenOdd)))
+				break
+			}
+		}
+	}
+
+	if addr.StreetNameFi != `` && addr.StreetNameSe != `` {
+		key := addr.PostalCode + `/` + addr.StreetNameFi
+		first, ok := l.swedishNames[key]
+		if !ok {
+			l.swedishNames[key] = lintName{se: addr.StreetNameSe, line: line}
+		} else if first.se != addr.StreetNameSe {
+			l.reportOnce(key+`/`+addr.StreetNameSe, finding(SWEDISHNAME, `Swedish name '%s' differs from '%s' on line %d`, addr.StreetNameSe, first.se, first.line))
+		}
+	}
+
+	first, ok := l.municipalityNames[addr.MunicipalityCode]
+	if !ok {
+		l.municipalityNames[addr.MunicipalityCode] = lintName{fi: addr.MunicipalityNameFi, se: addr.MunicipalityNameSe, line: line}
+	} else if first.fi != addr.MunicipalityNameFi || first.se != addr.MunicipalityNameSe {
+		l.reportOnce(addr.MunicipalityCode+`/`+addr.MunicipalityNameFi+`/`+addr.MunicipalityNameSe,
+			finding(MUNICIPALITYNAME, `names '%s' / '%s' differ from '%s' / '%s' on line %d`, addr.MunicipalityNameFi, addr.MunicipalityNameSe, first.fi, first.se, first.line))
+	}
+
+	for _, names := range [][2]string{{addr.PostalCodeNameFi, addr.PostalCodeShortNameFi}, {addr.PostalCodeNameSe, addr.PostalCodeShortNameSe}} {
+		if names[0] == `` || names[1] == `` || isAbbreviation(names[1], names[0]) {
+			continue
+		}
+
+		l.reportOnce(addr.PostalCode+`/`+names[0]+`/`+names[1], finding(ABBREVIATION, `abbreviation '%s' doesn't abbreviate postal code name '%s'`, names[1], names[0]))
+	}
+}
+
+// Last building number of building, -1 if it has none
+func buildingEnd(b Building) int64 {
+	if b.BuildingNumber2 >= 0 {
+		return b.BuildingNumber2
+	}
+
+	return b.BuildingNumber1
+}
+
+// Letters of abbreviation appear in name in the same order and both start with the same letter
+// Spaces, dots and hyphens are ignored, so "hki" abbreviates "helsinki" and "pl" "pohjois-lahti".
+func isAbbreviation(abbreviation string, name string) bool {
+	clean := strings.NewReplacer(` `, ``, `.`, ``, `-`, ``)
+	a := []rune(clean.Replace(strings.ToLower(abbreviation)))
+	n := []rune(clean.Replace(strings.ToLower(name)))
+
+	if len(a) == 0 || len(n) == 0 || a[0] != n[0] {
+		return false
+	}
+
+	i := 0
+	for _, r := range n {
+		if i < len(a) && a[i] == r {
+			i++
+		}
+	}
+
+	return i == len(a)
+}
@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestCheckRecordBuildingNumbers(t *testing.T) {
+	building := func(n1 int64, n2 int64) Building {
+		return Building{BuildingNumber1: n1, BuildingNumber2: n2}
+	}
+
+	tests := []struct {
+		name     string
+		smallest Building
+		highest  Building
+		evenOdd  EvenOdd
+		rules    []LintRule
+	}{
+		{`ordered`, building(1, -1), building(9, -1), ODD, nil},
+		{`ordered ranges`, building(2, 4), building(10, 12), EVEN, nil},
+		{`same building`, building(12, 14), building(12, 14), EVEN, nil},
+		{`smallest after highest`, building(9, -1), building(1, -1), ODD, []LintRule{BUILDINGRANGE}},
+		{`reversed building`, building(14, 12), building(20, -1), EVEN, []LintRule{BUILDINGRANGE}},
+		{`smallest ends after highest`, building(2, 24), building(20, -1), EVEN, []LintRule{BUILDINGRANGE}},
+		{`parity of number 2`, building(1, 4), building(9, -1), ODD, []LintRule{PARITY}},
+		{`parity of highest number 2`, building(2, -1), building(10, 13), EVEN, []LintRule{PARITY}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := newLinter(LintConfigJSON{})
+			l.checkRecord(1, StreetAddress{
+				PostalCode:              `00100`,
+				StreetNameFi:            `mannerheimintie`,
+				BuildingDataTypeEvenOdd: test.evenOdd,
+				SmallestBuilding:        test.smallest,
+				HighestBuilding:         test.highest,
+				MunicipalityCode:        `091`,
+			})
+
+			var rules []LintRule
+			for _, f := range l.findings {
+				rules = append(rules, f.Rule)
+			}
+
+			if len(rules) != len(test.rules) {
+				t.Fatalf(`findings %v, expected %v`, l.findings, test.rules)
+			}
+
+			for i := range rules {
+				if rules[i] != test.rules[i] {
+					t.Errorf(`finding %d rule %s, expected %s`, i, rules[i], test.rules[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"sort"
+)
+
+// Street found by lookup
+type LookupJSON struct {
+	PostalCode   string           `json:"postal"`
+	Postnumber   PostnumberJSON   `json:"postnumber"`
+	Municipality MunicipalityJSON `json:"municipality"`
+	Code         string           `json:"code"` // Municipality code
+	Street       StreetJSON       `json:"street"`
+}
+
+// Find streets matching filter in source file, names are in casing
+// Streets are aggregated per postal code the same way as in street.json and sorted by postal code and name.
+func LookupStreets(ctx context.Context, sourcefile string, filter Filter, casing Casing) (results []LookupJSON, err error) {
+	found := make(map[string]int) // Postal code and Finnish name -> index in results
+
+	err = ReadSourceFileCasing(ctx, sourcefile, casing, func(addr StreetAddress) error {
+		if addr.StreetNameFi == `` || !filter.Match(addr) {
+			return nil
+		}
+
+		key := addr.PostalCode + `/` + addr.StreetNameFi
+
+		var arr []int64
+		idx, ok := found[key]
+		if ok {
+			arr = []int64{results[idx].Street.Min, results[idx].Street.Max}
+		} else {
+			idx = len(results)
+			found[key] = idx
+			results = append(results, LookupJSON{
+				PostalCode: addr.PostalCode,
+				Postnumber: PostnumberJSON{
+					Fi:    addr.PostalCodeNameFi,
+					Se:    addr.PostalCodeNameSe,
+					FiLyh: addr.PostalCodeShortNameFi,
+					SeLyh: addr.PostalCodeShortNameSe,
+				},
+				Municipality: MunicipalityJSON{
+					Fi: addr.MunicipalityNameFi,
+					Se: addr.MunicipalityNameSe,
+				},
+				Code: addr.MunicipalityCode,
+				Street: StreetJSON{
+					Fi: addr.StreetNameFi,
+					Se: addr.StreetNameSe,
+				},
+			})
+		}
+
+		results[idx].Street.Min, results[idx].Street.Max = addr.StreetNumberMinMax(arr)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.PostalCode != b.PostalCode {
+			return a.PostalCode < b.PostalCode
+		}
+
+		return lessNames(`fi`, a.Street.Fi, a.Street.Se, b.Street.Fi, b.Street.Se)
+	})
+
+	return results, nil
+}
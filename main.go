@@ -1,55 +1,231 @@
 package main
 
 import (
-	"time"
-	"log"
+	"context"
+	"errors"
 	"flag"
-	"os"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
 )
 
-func main() {
-	var err error
+// Repeatable -layout flag
+type layoutFlags []Layout
 
-	sourceFile := flag.String("f", "", "File name (BAF_yyyymmdd.dat)")
-	outputDirectory := flag.String("o", "", "Output directory /home/user/jsonfiles")
+func (l *layoutFlags) String() string {
+	var s []string
+	for _, layout := range *l {
+		s = append(s, fmt.Sprintf(`%s=%s`, layout.Kind, layout.Template))
+	}
 
-	flag.Parse()
+	return strings.Join(s, `,`)
+}
 
-	required := []string{"f", "o"}
+func (l *layoutFlags) Set(s string) error {
+	layouts, err := ParseLayout(s)
+	if err != nil {
+		return err
+	}
 
-	seen := make(map[string]bool)
-	flag.Visit(func(f *flag.Flag) {
-		seen[f.Name] = true
-	})
+	*l = append(*l, layouts...)
+	return nil
+}
 
-	err = HasRequiredCommandLineArguments(required, seen)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "%v", err)
-		os.Exit(2)
+// Tool version, set at build time with -ldflags "-X main.Version=1.0.0"
+var Version = "dev"
+
+// Exit codes
+const (
+	ExitOK        = 0 // Success
+	ExitFailure   = 1 // Check failed or other error, for example verify found differences or interrupted
+	ExitUsage     = 2 // Invalid command line
+	ExitDataError = 3 // Invalid source file record
+	ExitIOError   = 4 // Reading or writing files failed
+)
+
+// Exit code for error returned by a command
+func exitCode(err error) int {
+	var sourceErr *SourceError
+	var fieldErr *FixedFieldError
+	var pathErr *os.PathError
+	var linkErr *os.LinkError
+	var syscallErr *os.SyscallError
+
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &sourceErr), errors.As(err, &fieldErr):
+		return ExitDataError
+	case errors.As(err, &pathErr), errors.As(err, &linkErr), errors.As(err, &syscallErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return ExitIOError
 	}
 
-	_, err = os.Stat(*sourceFile)
+	return ExitFailure
+}
 
-	if err != nil {
-		if os.IsNotExist(err) {
-			fmt.Fprintf(os.Stderr, "File not found: '%v'", *sourceFile)
-			os.Exit(2)
-		} else {
-			panic(err)
+// Print error and give its exit code
+func fail(err error) int {
+	fmt.Fprintf(os.Stderr, "Error: '%v'\n", err)
+	return exitCode(err)
+}
+
+// Print invalid usage followed by command usage
+func usageError(fs *flag.FlagSet, format string, a ...interface{}) int {
+	fmt.Fprintf(fs.Output(), format+"\n\n", a...)
+	fs.Usage()
+	return ExitUsage
+}
+
+type command struct {
+	name    string
+	args    string // Arguments in usage
+	summary string
+	run     func(fs *flag.FlagSet, args []string) int
+}
+
+var commands = []command{
+	{`convert`, `-f <source file> -o <output directory>`, `Convert source file to JSON files`, convert},
+	{`stats`, `-f <source file> [-format text|json|html]`, `Report dataset statistics as text, JSON or HTML`, stats},
+	{`validate`, `-f <source file>`, `Check that every record of source file can be decoded`, validate},
+	{`lint`, `-f <source file> [-config <lint config file>]`, `Report data quality findings with line numbers and severities`, lint},
+	{`diff`, `-f <source file> -d <previous source file>`, `List added, removed and renamed streets as JSON`, diff},
+	{`lookup`, `-f <source file> <filters>`, `Find streets matching filters and print them as JSON`, lookup},
+	{`trace`, `-f <source file> -postal <postal code> -street <street name>`, `Print source lines of a street decoded field by field`, trace},
+	{`extract`, `-f <source file> [-o <output file>] [filters]`, `Write matching original lines to a smaller source file`, extract},
+	{`serve`, `-d <output directory>`, `Serve converted JSON files over HTTP`, serve},
+	{`verify`, `<output directory>`, `Check output directory against its manifest`, verify},
+	{`fields`, ``, `Print source file field table`, fields},
+	{`schema`, `[-schema v1|v2] <file type>`, `Print JSON Schema document of an output file type`, schema},
+}
+
+// Flag set of command, usage lists its flags
+func newFlagSet(cmd command) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s %s\n\n%s.\n\nFlags:\n", os.Args[0], cmd.name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+
+	fmt.Fprintf(w, "\nRun '%s help <command>' for the flags of a command.\n", os.Args[0])
+	fmt.Fprintf(w, "Flags without a command are passed to convert.\n\n")
+	fmt.Fprintf(w, "Exit codes:\n")
+	fmt.Fprintf(w, "  %d  success\n", ExitOK)
+	fmt.Fprintf(w, "  %d  check failed or other error\n", ExitFailure)
+	fmt.Fprintf(w, "  %d  invalid usage\n", ExitUsage)
+	fmt.Fprintf(w, "  %d  invalid source file data\n", ExitDataError)
+	fmt.Fprintf(w, "  %d  I/O error\n", ExitIOError)
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
 		}
+	}
 
+	return command{}, false
+}
+
+// Run command line and give exit code
+func run(args []string) int {
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return ExitUsage
 	}
 
-	log.Printf("Source file: '%s'", *sourceFile)
-	log.Printf("Output directory: '%s'", *outputDirectory)
+	switch args[0] {
+	case `help`, `-h`, `-help`, `--help`:
+		if len(args) > 1 {
+			cmd, ok := findCommand(args[1])
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Unknown command '%s'\n\n", args[1])
+				usage(os.Stderr)
+				return ExitUsage
+			}
 
-	starttime := time.Now().UTC()
+			// Parsing -h prints usage and exits
+			fs := newFlagSet(cmd)
+			fs.SetOutput(os.Stdout)
+			return cmd.run(fs, []string{`-h`})
+		}
 
-	err = ConvertFile(*sourceFile, *outputDirectory)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: '%v'", err)
+		usage(os.Stdout)
+		return ExitOK
+	case `version`, `-version`:
+		fmt.Println(Version)
+		return ExitOK
+	}
+
+	// Flags without command, same as convert
+	if strings.HasPrefix(args[0], `-`) {
+		args = append([]string{`convert`}, args...)
+	}
+
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command '%s'\n\n", args[0])
+		usage(os.Stderr)
+		return ExitUsage
+	}
+
+	return cmd.run(newFlagSet(cmd), args[1:])
+}
+
+// Add record filter flags to fs, the returned function gives the Filter after fs is parsed
+func addFilterFlags(fs *flag.FlagSet) func() (Filter, error) {
+	municipalities := fs.String("municipality", "", "Comma-separated municipality codes or Finnish or Swedish names, for example 091,espoo")
+	postalRanges := fs.String("postal", "", "Comma-separated postal codes, ranges or prefixes, for example 00100-00990,02100,021")
+	bilingual := fs.Bool("bilingual", false, "Only streets with both Finnish and Swedish name")
+	streetPattern := fs.String("street", "", "Regular expression matched against Finnish and Swedish street names, for example (?i)^mannerheim")
+
+	return func() (filter Filter, err error) {
+		filter.BilingualOnly = *bilingual
+
+		if *municipalities != "" {
+			for _, s := range strings.Split(*municipalities, ",") {
+				filter.AddMunicipality(s)
+			}
+		}
+
+		if *postalRanges != "" {
+			for _, s := range strings.Split(*postalRanges, ",") {
+				r, err := ParsePostalRange(s)
+				if err != nil {
+					return filter, err
+				}
+
+				filter.PostalRanges = append(filter.PostalRanges, r)
+			}
+		}
+
+		if *streetPattern != "" {
+			filter.StreetPattern, err = regexp.Compile(*streetPattern)
+			if err != nil {
+				return filter, fmt.Errorf("invalid street pattern: %v", err)
+			}
+		}
+
+		return filter, nil
 	}
+}
 
-	log.Printf("Took %s", time.Now().UTC().Sub(starttime))
+// Context cancelled on Ctrl+C
+func interruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt)
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
 }
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/spf13/afero"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// Manifest file at the root of output directory
+const ManifestFile = `manifest.json`
+
+type ManifestJSON struct {
+	Version string             `json:"version"`          // Tool version
+	Schema  SchemaVersion      `json:"schema,omitempty"` // Output schema, empty is v1
+	Source  SourceInfo         `json:"source"`           // Converted source file
+	Files   []ManifestFileJSON `json:"files"`            // Every file except the manifest, sorted by path
+}
+
+type ManifestFileJSON struct {
+	Path   string `json:"path"`   // Path relative to output directory
+	Size   int64  `json:"size"`   // Size in bytes
+	SHA256 string `json:"sha256"` // Hex encoded checksum
+}
+
+// Files found to differ from manifest
+type VerifyReport struct {
+	Missing  []string // Listed in manifest but not found
+	Extra    []string // Found but not listed in manifest
+	Modified []string // Size or checksum differs
+}
+
+func (r VerifyReport) OK() bool {
+	return len(r.Missing) == 0 && len(r.Extra) == 0 && len(r.Modified) == 0
+}
+
+// Generate /manifest.json listing every generated file
+func GenerateManifest(fs *afero.Afero, info SourceInfo, schema SchemaVersion) error {
+	manifest := ManifestJSON{
+		Version: Version,
+		Source:  info,
+		Files:   []ManifestFileJSON{},
+	}
+
+	// v1 manifest stays as it was
+	if schema != V1 {
+		manifest.Schema = schema
+	}
+
+	err := fs.Walk(`/`, func(fName string, fInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if fInfo.IsDir() || fName == path.Join(`/`, ManifestFile) {
+			return nil
+		}
+
+		b, err := fs.ReadFile(fName)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(b)
+
+		manifest.Files = append(manifest.Files, ManifestFileJSON{
+			Path:   fName[1:],
+			Size:   int64(len(b)),
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Path < manifest.Files[j].Path
+	})
+
+	b, err := json.MarshalIndent(manifest, ``, `  `)
+	if err != nil {
+		return err
+	}
+
+	return fs.WriteFile(path.Join(`/`, ManifestFile), append(b, '\n'), os.FileMode(0600))
+}
+
+// Read manifest of output directory
+func ReadManifest(dir string) (manifest ManifestJSON, err error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return manifest, err
+	}
+
+	err = json.Unmarshal(b, &manifest)
+	return manifest, err
+}
+
+// Check output directory against its manifest
+func VerifyDirectory(dir string) (report VerifyReport, err error) {
+	// Follow for example <output>/latest
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		return report, err
+	}
+
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return report, err
+	}
+
+	listed := make(map[string]bool)
+
+	for _, f := range manifest.Files {
+		listed[f.Path] = true
+
+		fName := filepath.Join(dir, filepath.FromSlash(f.Path))
+
+		fi, err := os.Stat(fName)
+		if err != nil {
+			if os.IsNotExist(err) {
+				report.Missing = append(report.Missing, f.Path)
+				continue
+			}
+
+			return report, err
+		}
+
+		if fi.Size() != f.Size {
+			report.Modified = append(report.Modified, f.Path)
+			continue
+		}
+
+		sum, err := FileSHA256(fName)
+		if err != nil {
+			return report, err
+		}
+
+		if sum != f.SHA256 {
+			report.Modified = append(report.Modified, f.Path)
+		}
+	}
+
+	err = filepath.Walk(dir, func(fName string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if fi.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, fName)
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+		if rel != ManifestFile && !listed[rel] {
+			report.Extra = append(report.Extra, rel)
+		}
+
+		return nil
+	})
+
+	return report, err
+}
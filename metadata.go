@@ -0,0 +1,71 @@
+package main
+
+import (
+	"github.com/spf13/afero"
+	"os"
+	"path"
+	"time"
+)
+
+// Metadata file in every output directory
+const MetadataFile = `meta.json`
+
+// Source release and generation of output
+type MetadataJSON struct {
+	RunningDate string `json:"runningdate"`         // Running date of source file yyyymmdd
+	FileName    string `json:"file"`                // Source file name without directory
+	SHA256      string `json:"sha256"`              // Source file checksum
+	Version     string `json:"version"`             // Tool version
+	Generated   string `json:"generated,omitempty"` // Generation time, RFC 3339
+}
+
+// Metadata of converted source file, generation time is left out if zero
+func NewMetadata(info SourceInfo, generated time.Time) MetadataJSON {
+	meta := MetadataJSON{
+		RunningDate: info.RunningDate,
+		FileName:    info.FileName,
+		SHA256:      info.SHA256,
+		Version:     Version,
+	}
+
+	if !generated.IsZero() {
+		meta.Generated = generated.UTC().Format(time.RFC3339)
+	}
+
+	return meta
+}
+
+// Write meta.json to every directory except the schema directory
+// Returns paths of generated metadata files
+func GenerateMetadata(fs *afero.Afero, meta MetadataJSON) (files []string, err error) {
+	err = fs.Walk(`/`, func(fName string, fInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !fInfo.IsDir() {
+			return nil
+		}
+
+		if fName == path.Join(`/`, SchemaDir) {
+			return nil
+		}
+
+		files = append(files, path.Join(fName, MetadataFile))
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't add files to directories while walking them
+	for _, fName := range files {
+		err = SaveData(fs, fName, meta)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
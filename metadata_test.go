@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/spf13/afero"
+	"testing"
+	"time"
+)
+
+func TestGenerateMetadataEveryDirectory(t *testing.T) {
+	fs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	for _, fName := range []string{`/091/00100/street.json`, `/schema/street.schema.json`} {
+		err := fs.WriteFile(fName, []byte(`[]`), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	generated := time.Unix(1514764800, 0)
+	meta := NewMetadata(SourceInfo{RunningDate: `20180101`, FileName: `BAF_20180101.dat`, SHA256: `abc`}, generated)
+
+	files, err := GenerateMetadata(fs, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 3 {
+		t.Fatalf(`metadata files %v, expected /, /091 and /091/00100`, files)
+	}
+
+	for _, fName := range files {
+		var got MetadataJSON
+		err = ConvertFromFile(fName, fs, &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != meta {
+			t.Errorf(`%s: %+v, expected %+v`, fName, got, meta)
+		}
+
+		if got.Generated != `2018-01-01T00:00:00Z` {
+			t.Errorf(`%s: generated '%s'`, fName, got.Generated)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+)
+
+// Letters which don't decompose to an ASCII letter and a combining mark
+var foldLetters = map[rune]string{
+	'æ': `ae`,
+	'ø': `o`,
+	'ð': `d`,
+	'þ': `th`,
+	'ß': `ss`,
+}
+
+// Search keys and slugs of Finnish and Swedish names
+func SearchKeysFor(fi string, se string) NameKeysV2JSON {
+	return NameKeysV2JSON{
+		FiKey:  SearchKey(fi),
+		SvKey:  SearchKey(se),
+		FiSlug: Slug(fi),
+		SvSlug: Slug(se),
+	}
+}
+
+// Name in Unicode normalization form C
+func NormalizeName(s string) string {
+	return norm.NFC.String(s)
+}
+
+// Lower case letters without diacritics, "Etelä-Haaga" is "etela-haaga"
+func foldName(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+
+		if folded, ok := foldLetters[r]; ok {
+			sb.WriteString(folded)
+			continue
+		}
+
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+// Search key of name, lower case without diacritics, hyphens and white space collapsed to one space
+// "Etelä-Haaga" and "ETELÄ  HAAGA" both give "etela haaga".
+func SearchKey(s string) string {
+	return strings.Join(strings.FieldsFunc(foldName(s), func(r rune) bool {
+		return r == '-' || unicode.IsSpace(r)
+	}), ` `)
+}
+
+// ASCII slug of name for URLs, "Kustaa III:n katu" is "kustaa-iii-n-katu"
+func Slug(s string) string {
+	return strings.Join(strings.FieldsFunc(foldName(s), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9')
+	}), `-`)
+}
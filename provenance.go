@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// Inclusive ranges of 1-based source file lines, for example [[12, 40], [97, 97]]
+// Records of a street or postal code are consecutive in Posti's sorted files, so ranges stay short.
+type LineRanges [][2]int64
+
+// Ranges with line added, lines must be added in increasing order and 0 (unknown) is ignored
+func (r LineRanges) Add(line int64) LineRanges {
+	if line <= 0 {
+		return r
+	}
+
+	if len(r) > 0 && r[len(r)-1][1] >= line-1 {
+		r[len(r)-1][1] = line
+		return r
+	}
+
+	return append(r, [2]int64{line, line})
+}
+
+// Byte offset of the start of 1-based line in source file
+func LineOffset(line int64) int64 {
+	return (line - 1) * int64(rawRecordSize+1)
+}
+
+// Call fn with every source line of postal code and street
+// Street is matched case-insensitively against Finnish and Swedish names. The record slice is only valid until fn returns.
+func TraceFile(ctx context.Context, sourcefile string, postalCode string, street string, fn func(line int64, record []byte) error) error {
+	f, err := os.Open(sourcefile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	decoder := NewRecordDecoderCasing(RAW)
+
+	return ReadRawRecords(ctx, f, func(line int64, record []byte) error {
+		// Postal code is compared before decoding the whole record
+		if string(trimField(record, rawPostalCode)) != postalCode {
+			return nil
+		}
+
+		addr, err := decoder.Decode(record)
+		if err != nil {
+			return &SourceError{line, err}
+		}
+
+		if !strings.EqualFold(addr.StreetNameFi, street) && !strings.EqualFold(addr.StreetNameSe, street) {
+			return nil
+		}
+
+		return fn(line, record)
+	})
+}
+
+// Write raw record and its fields one per line with field number, column, length and description
+func WriteTraceRecord(w io.Writer, line int64, record []byte) error {
+	fields, err := FixedLayout(reflect.TypeOf(RawLineStructure{}))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Line %d, byte offset %d:\n%s\n", line, LineOffset(line), latin1ToUTF8(record))
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	for _, f := range fields {
+		fmt.Fprintf(tw, "  #%d\t%d\t%d\t%s\t'%s'\n", f.No, f.Pos, f.Len, f.Doc, latin1ToUTF8(record[f.Pos-1:f.Pos-1+f.Len]))
+	}
+
+	err = tw.Flush()
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/afero"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Write generated files to a new hidden sibling directory of targetdir and then point the targetdir symlink to it
+// On any error the previous targetdir is left untouched
+// In incremental mode unchanged files are linked from the previous output, see WriteFilesIncremental
+func PublishFiles(fs *afero.Afero, targetdir string, options ConvertOptions) (err error) {
+	targetdir = filepath.Clean(targetdir)
+	parent := filepath.Dir(targetdir)
+
+	err = os.MkdirAll(parent, os.FileMode(0700))
+	if err != nil {
+		return err
+	}
+
+	tmpdir, err := ioutil.TempDir(parent, publishedPrefix(targetdir))
+	if err != nil {
+		return err
+	}
+
+	var stats WriteStats
+
+	if options.Incremental {
+		// Compare with the directory behind the symlink, walking the symlink itself would find nothing
+		previous, err := filepath.EvalSymlinks(targetdir)
+		if err != nil && !os.IsNotExist(err) {
+			os.RemoveAll(tmpdir)
+			return err
+		}
+
+		stats, err = WriteFilesIncremental(fs, tmpdir, previous, options)
+	} else {
+		err = WriteFiles(fs, tmpdir, options)
+	}
+
+	if err != nil {
+		os.RemoveAll(tmpdir)
+		return err
+	}
+
+	err = PublishDirectory(tmpdir, targetdir)
+	if err != nil {
+		os.RemoveAll(tmpdir)
+		return err
+	}
+
+	if options.Incremental {
+		log.Printf(`Created %d, updated %d, unchanged %d, deleted %d files`, stats.Created, stats.Updated, stats.Unchanged, stats.Deleted)
+	}
+
+	return nil
+}
+
+// Existing output directory wasn't written by this tool and is never replaced
+var ErrNotOutputDirectory = errors.New(`not empty and has no manifest.json or meta.json, refusing to replace it`)
+
+// Directory is empty or has the manifest or metadata of earlier output
+func isOutputDirectory(dir string) (bool, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil || len(entries) == 0 {
+		return err == nil, err
+	}
+
+	if _, err = ReadManifest(dir); err == nil {
+		return true, nil
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, MetadataFile))
+	if err != nil {
+		return false, nil
+	}
+
+	var meta MetadataJSON
+	return json.Unmarshal(b, &meta) == nil && meta.SHA256 != ``, nil
+}
+
+// Name prefix of hidden directories behind targetdir symlink
+func publishedPrefix(targetdir string) string {
+	return `.` + filepath.Base(targetdir) + `.`
+}
+
+// Point targetdir symlink to srcdir, a sibling directory of targetdir, and remove the directory it pointed to before
+// The symlink is replaced with a rename, so readers see either the previous or the new tree and never a missing targetdir.
+// A real targetdir directory written by earlier versions is renamed aside first, only that one swap isn't atomic.
+// Any other non-empty directory is left alone and ErrNotOutputDirectory is returned.
+func PublishDirectory(srcdir string, targetdir string) (err error) {
+	fi, err := os.Lstat(targetdir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var olddir string
+	migrated := false
+
+	switch {
+	case err != nil:
+		// Nothing published yet
+	case fi.Mode()&os.ModeSymlink != 0:
+		olddir, err = publishedDirectory(targetdir)
+		if err != nil {
+			return err
+		}
+	case fi.IsDir():
+		ours, err := isOutputDirectory(targetdir)
+		if err != nil {
+			return err
+		}
+
+		if !ours {
+			return &os.PathError{Op: `publish`, Path: targetdir, Err: ErrNotOutputDirectory}
+		}
+
+		olddir, err = ioutil.TempDir(filepath.Dir(targetdir), publishedPrefix(targetdir)+`old-`)
+		if err != nil {
+			return err
+		}
+
+		// Rename over the empty placeholder directory
+		err = os.Remove(olddir)
+		if err != nil {
+			return err
+		}
+
+		log.Printf(`Replacing directory '%s' with a symlink, the directory is missing for a moment this time only`, targetdir)
+
+		err = os.Rename(targetdir, olddir)
+		if err != nil {
+			return err
+		}
+
+		migrated = true
+	default:
+		return fmt.Errorf(`'%s' exists and is not a directory or a symlink`, targetdir)
+	}
+
+	// Relative link so that the parent directory can be moved
+	err = ReplaceSymlink(targetdir, filepath.Base(srcdir))
+	if err != nil {
+		if migrated {
+			if rerr := os.Rename(olddir, targetdir); rerr != nil {
+				log.Printf(`Could not restore '%s' from '%s': %v`, targetdir, olddir, rerr)
+			}
+		}
+
+		return err
+	}
+
+	if olddir == `` {
+		return nil
+	}
+
+	return os.RemoveAll(olddir)
+}
+
+// Hidden directory which targetdir symlink points to, empty if the link wasn't created by PublishDirectory
+func publishedDirectory(targetdir string) (dir string, err error) {
+	target, err := os.Readlink(targetdir)
+	if err != nil {
+		return ``, err
+	}
+
+	if target != filepath.Base(target) || !strings.HasPrefix(target, publishedPrefix(targetdir)) {
+		return ``, nil
+	}
+
+	return filepath.Join(filepath.Dir(targetdir), target), nil
+}
+
+// Remove published targetdir, both the symlink and the directory behind it
+// A real directory is only removed when it's empty or earlier output, otherwise ErrNotOutputDirectory is returned.
+func RemovePublished(targetdir string) error {
+	fi, err := os.Lstat(targetdir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	if fi.Mode()&os.ModeSymlink != 0 {
+		dir, err := publishedDirectory(targetdir)
+		if err != nil {
+			return err
+		}
+
+		if dir != `` {
+			err = os.RemoveAll(dir)
+			if err != nil {
+				return err
+			}
+		}
+
+		return os.Remove(targetdir)
+	}
+
+	if fi.IsDir() {
+		ours, err := isOutputDirectory(targetdir)
+		if err != nil {
+			return err
+		}
+
+		if !ours {
+			return &os.PathError{Op: `remove`, Path: targetdir, Err: ErrNotOutputDirectory}
+		}
+	}
+
+	return os.RemoveAll(targetdir)
+}
+
+// Atomically point symlink link to target, an existing symlink is replaced
+func ReplaceSymlink(link string, target string) error {
+	tmpName := filepath.Join(filepath.Dir(link), `.`+filepath.Base(link)+`.tmp`)
+
+	err := os.Remove(tmpName)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	err = os.Symlink(target, tmpName)
+	if err != nil {
+		return err
+	}
+
+	fi, err := os.Lstat(link)
+	if err == nil && fi.Mode()&os.ModeSymlink == 0 {
+		os.Remove(tmpName)
+		return fmt.Errorf(`'%s' exists and is not a symlink`, link)
+	}
+
+	err = os.Rename(tmpName, link)
+	if err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	return nil
+}
+
+// Write in-memory files to dir
+func WriteFiles(fs *afero.Afero, dir string, options ConvertOptions) (err error) {
+	log.Printf(`Generating directories..`)
+	// Create directories
+	err = fs.Walk(`/`, func(fName string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			// Skip files
+			return nil
+		}
+
+		dirPath := path.Join(dir, fName)
+
+		err = os.MkdirAll(dirPath, os.FileMode(0700))
+		if err != nil {
+			return err
+		}
+
+		if options.Deterministic {
+			// Existing directories and umask would otherwise affect the mode
+			return os.Chmod(dirPath, os.FileMode(0700))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Memory files to actual files
+	log.Printf(`Saving files..`)
+	return fs.Walk(`/`, func(fName string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			// Skip directories
+			return nil
+		}
+
+		b, err := fs.ReadFile(fName)
+		if err != nil {
+			return err
+		}
+
+		dirPath := path.Join(dir, fName)
+		err = ioutil.WriteFile(dirPath, b, os.FileMode(0600))
+		if err != nil {
+			return err
+		}
+
+		if options.Deterministic {
+			return os.Chmod(dirPath, os.FileMode(0600))
+		}
+
+		return nil
+	})
+}
+
+// Incremental write results
+type WriteStats struct {
+	Created   int
+	Updated   int
+	Unchanged int
+	Deleted   int
+}
+
+// Write in-memory files to dir like WriteFiles, files unchanged since previous output directory are hard linked from it
+// Unchanged files keep their modification time and files of previous not generated anymore are counted as deleted.
+// previous is empty when there's no previous output, then every file is created.
+func WriteFilesIncremental(fs *afero.Afero, dir string, previous string, options ConvertOptions) (stats WriteStats, err error) {
+	log.Printf(`Saving changed files..`)
+	err = fs.Walk(`/`, func(fName string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		dirPath := path.Join(dir, fName)
+
+		if info.IsDir() {
+			err = os.MkdirAll(dirPath, os.FileMode(0700))
+			if err != nil {
+				return err
+			}
+
+			if options.Deterministic {
+				return os.Chmod(dirPath, os.FileMode(0700))
+			}
+
+			return nil
+		}
+
+		b, err := fs.ReadFile(fName)
+		if err != nil {
+			return err
+		}
+
+		previousPath := path.Join(previous, fName)
+		found := false
+		var existing []byte
+
+		if previous != `` {
+			existing, err = ioutil.ReadFile(previousPath)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+
+			found = err == nil
+		}
+
+		if found && bytes.Equal(existing, b) {
+			stats.Unchanged++
+
+			err = linkUnchanged(previousPath, dirPath, b)
+			if err != nil {
+				return err
+			}
+
+			if options.Deterministic {
+				return os.Chmod(dirPath, os.FileMode(0600))
+			}
+
+			return nil
+		}
+
+		if found {
+			stats.Updated++
+		} else {
+			stats.Created++
+		}
+
+		return ioutil.WriteFile(dirPath, b, os.FileMode(0600))
+	})
+
+	if err != nil || previous == `` {
+		return stats, err
+	}
+
+	err = filepath.Walk(previous, func(fName string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(previous, fName)
+		if err != nil {
+			return err
+		}
+
+		exists, err := fs.Exists(path.Join(`/`, filepath.ToSlash(rel)))
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			stats.Deleted++
+		}
+
+		return nil
+	})
+
+	return stats, err
+}
+
+// Hard link unchanged file from previous output, or copy it with its modification time where links aren't supported
+func linkUnchanged(previousPath string, dirPath string, b []byte) error {
+	if os.Link(previousPath, dirPath) == nil {
+		return nil
+	}
+
+	fi, err := os.Stat(previousPath)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(dirPath, b, os.FileMode(0600))
+	if err != nil {
+		return err
+	}
+
+	return os.Chtimes(dirPath, fi.ModTime(), fi.ModTime())
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// New hidden directory with a file to be published as targetdir
+func testPublishedDir(t *testing.T, targetdir string) string {
+	dir, err := ioutil.TempDir(filepath.Dir(targetdir), publishedPrefix(targetdir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, `postal_codes.json`), []byte(`{}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func TestPublishDirectoryRefusesForeignDirectory(t *testing.T) {
+	targetdir := filepath.Join(t.TempDir(), `out`)
+	foreign := filepath.Join(targetdir, `notes.txt`)
+
+	err := os.Mkdir(targetdir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(foreign, []byte(`not converter output`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = PublishDirectory(testPublishedDir(t, targetdir), targetdir)
+	if !errors.Is(err, ErrNotOutputDirectory) {
+		t.Fatalf(`PublishDirectory() = %v, expected ErrNotOutputDirectory`, err)
+	}
+
+	if exitCode(err) != ExitIOError {
+		t.Errorf(`exit code %d, expected %d`, exitCode(err), ExitIOError)
+	}
+
+	if _, err = os.Stat(foreign); err != nil {
+		t.Errorf(`foreign file was removed: %v`, err)
+	}
+
+	err = RemovePublished(targetdir)
+	if !errors.Is(err, ErrNotOutputDirectory) {
+		t.Fatalf(`RemovePublished() = %v, expected ErrNotOutputDirectory`, err)
+	}
+
+	if _, err = os.Stat(foreign); err != nil {
+		t.Errorf(`foreign file was removed: %v`, err)
+	}
+}
+
+func TestPublishDirectoryReplacesEarlierOutput(t *testing.T) {
+	targetdir := filepath.Join(t.TempDir(), `out`)
+
+	err := os.Mkdir(targetdir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(targetdir, MetadataFile), []byte(`{"sha256":"abc"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcdir := testPublishedDir(t, targetdir)
+
+	err = PublishDirectory(srcdir, targetdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := publishedDirectory(targetdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dir != srcdir {
+		t.Errorf(`'%s' points to '%s', expected '%s'`, targetdir, dir, srcdir)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(targetdir), publishedPrefix(targetdir)+`old-*`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(matches) != 0 {
+		t.Errorf(`earlier output left behind: %v`, matches)
+	}
+}
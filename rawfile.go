@@ -1,36 +1,38 @@
 package main
 
+//go:generate go run . fields -readme README.md
+
 // Basic Address File Record Description
-// Raw data
+// Raw data, the layout is defined by the fixed tags, see fixedwidth.go
 type RawLineStructure struct {
-	RecordIdentifier      [5]byte  // #1 Record identifier, "KATUN"
-	RunningDate           [8]byte  // #2 Running date, numeric date yyyymmdd
-	PostalCode            [5]byte  // #3 Postal code, numeric
-	PostalCodeNameFi      [30]byte // #4 Postal code name in Finnish
-	PostalCodeNameSe      [30]byte // #5 Postal code name in Swedish, optional
-	PostalCodeShortNameFi [12]byte // #6 Postal code name abbreviation in Finnish
-	PostalCodeShortNameSe [12]byte // #7 Postal code name abbreviation in Swedish, optional
-	StreetNameFi          [30]byte // #8 Street (location) name in Finnish
-	StreetNameSe          [30]byte // #9 Street (location) name in Swedish, optional
-	Blank1                [12]byte // #10 Blank
-	Blank2                [12]byte // #11 Blank
-	BuildingDataType      [1]byte  // #12 Building data type, 1 = odd 2 = even
+	RecordIdentifier      [5]byte  `fixed:"no=1,pos=1,len=5" doc:"Record identifier" example:"'KATUN'"`                                // #1 Record identifier, "KATUN"
+	RunningDate           [8]byte  `fixed:"no=2,pos=6,len=8,numeric" doc:"Running date" example:"yyyymmdd, '20171231'"`                // #2 Running date, numeric date yyyymmdd
+	PostalCode            [5]byte  `fixed:"no=3,pos=14,len=5,numeric" doc:"Postal code" example:"'40100'"`                             // #3 Postal code, numeric
+	PostalCodeNameFi      [30]byte `fixed:"no=4,pos=19,len=30,trim" doc:"Postal code name in Finnish" example:"'Jyväskylä'"`           // #4 Postal code name in Finnish
+	PostalCodeNameSe      [30]byte `fixed:"no=5,pos=49,len=30,optional,trim" doc:"Postal code name in Swedish"`                        // #5 Postal code name in Swedish, optional
+	PostalCodeShortNameFi [12]byte `fixed:"no=6,pos=79,len=12,trim" doc:"Postal code name abbreviation in Finnish" example:"'jkl'"`    // #6 Postal code name abbreviation in Finnish
+	PostalCodeShortNameSe [12]byte `fixed:"no=7,pos=91,len=12,optional,trim" doc:"Postal code name abbreviation in Swedish"`           // #7 Postal code name abbreviation in Swedish, optional
+	StreetNameFi          [30]byte `fixed:"no=8,pos=103,len=30,trim" doc:"Street (location) name in Finnish" example:"'vapaudenkatu'"` // #8 Street (location) name in Finnish
+	StreetNameSe          [30]byte `fixed:"no=9,pos=133,len=30,optional,trim" doc:"Street (location) name in Swedish"`                 // #9 Street (location) name in Swedish, optional
+	Blank1                [12]byte `fixed:"no=10,pos=163,len=12" doc:"Blank" example:"' ' x 12"`                                       // #10 Blank
+	Blank2                [12]byte `fixed:"no=11,pos=175,len=12" doc:"Blank" example:"' ' x 12"`                                       // #11 Blank
+	BuildingDataType      [1]byte  `fixed:"no=12,pos=187,len=1,optional,numeric" doc:"Building data type" example:"1 = odd, 2 = even"` // #12 Building data type, 1 = odd 2 = even
 
 	// #13 (skipped) Smallest building number (information about an odd/even building)
-	SmallestBuildingNumber1         [5]byte // #14 Building number 1, optional
-	SmallestBuildingDeliveryLetter1 [1]byte // #15 Building delivery letter 1, optional
-	SmallestPunctuationMark         [1]byte // #16 Punctuation mark, optional
-	SmallestBuildingNumber2         [5]byte // #17 Building number 2, optional
-	SmallestBuildingDeliveryLetter2 [1]byte // #18 Building delivery letter 2, optional
+	SmallestBuildingNumber1         [5]byte `fixed:"no=14,pos=188,len=5,optional,numeric,trim" doc:"Smallest building number 1" example:"'1'" group:"13:Smallest building number (information about an odd/even building)"` // #14 Building number 1, optional
+	SmallestBuildingDeliveryLetter1 [1]byte `fixed:"no=15,pos=193,len=1,optional" doc:"Smallest building delivery letter 1" example:"'A'"`                                                                                  // #15 Building delivery letter 1, optional
+	SmallestPunctuationMark         [1]byte `fixed:"no=16,pos=194,len=1,optional" doc:"Smallest building punctuation mark" example:"'/'"`                                                                                   // #16 Punctuation mark, optional
+	SmallestBuildingNumber2         [5]byte `fixed:"no=17,pos=195,len=5,optional,numeric,trim" doc:"Smallest building number 2" example:"'10'"`                                                                             // #17 Building number 2, optional
+	SmallestBuildingDeliveryLetter2 [1]byte `fixed:"no=18,pos=200,len=1,optional" doc:"Smallest building delivery letter 2" example:"'C'"`                                                                                  // #18 Building delivery letter 2, optional
 
 	// #19 (skipped) Highest building number (information about an odd/even building)
-	HighestBuildingNumber1         [5]byte // #20 Building number 1, optional
-	HighestBuildingDeliveryLetter1 [1]byte // #21 Building delivery letter 1, optional
-	HighestPunctuationMark         [1]byte // #22 Punctuation mark, optional
-	HighestBuildingNumber2         [5]byte // #23 Building number 2, optional
-	HighestBuildingDeliveryLetter2 [1]byte // #24 Building delivery letter 2, optional
+	HighestBuildingNumber1         [5]byte `fixed:"no=20,pos=201,len=5,optional,numeric,trim" doc:"Highest building number 1" example:"'10'" group:"19:Highest building number (information about an odd/even building)"` // #20 Building number 1, optional
+	HighestBuildingDeliveryLetter1 [1]byte `fixed:"no=21,pos=206,len=1,optional" doc:"Highest building delivery letter 1" example:"'F'"`                                                                                  // #21 Building delivery letter 1, optional
+	HighestPunctuationMark         [1]byte `fixed:"no=22,pos=207,len=1,optional" doc:"Highest building punctuation mark" example:"'-'"`                                                                                   // #22 Punctuation mark, optional
+	HighestBuildingNumber2         [5]byte `fixed:"no=23,pos=208,len=5,optional,numeric,trim" doc:"Highest building number 2" example:"'11'"`                                                                             // #23 Building number 2, optional
+	HighestBuildingDeliveryLetter2 [1]byte `fixed:"no=24,pos=213,len=1,optional" doc:"Highest building delivery letter 2" example:"'E'"`                                                                                  // #24 Building delivery letter 2, optional
 
-	MunicipalityCode   [3]byte  // #25 Municipality code, numeric
-	MunicipalityNameFi [20]byte // #26 Municipality name in Finnish
-	MunicipalityNameSe [20]byte // #27 Municipality name in Swedish, optional
+	MunicipalityCode   [3]byte  `fixed:"no=25,pos=214,len=3,numeric" doc:"Municipality code"`                   // #25 Municipality code, numeric
+	MunicipalityNameFi [20]byte `fixed:"no=26,pos=217,len=20,trim" doc:"Municipality name in Finnish"`          // #26 Municipality name in Finnish
+	MunicipalityNameSe [20]byte `fixed:"no=27,pos=237,len=20,optional,trim" doc:"Municipality name in Swedish"` // #27 Municipality name in Swedish, optional
 }
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Records read and decoded at a time
+const readChunkRecords = 4096
+
+// Invalid record in source file
+type SourceError struct {
+	Line int64 // 1-based line number
+	Err  error
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf(`line %d: %v`, e.Line, e.Err)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// Chunk of raw records
+type rawChunk struct {
+	seq     int    // Chunk number from start of file
+	data    []byte // Records, each followed by new line
+	records int    // Number of records in data
+	end     int64  // File position after chunk
+}
+
+// Chunk of decoded records
+type decodedChunk struct {
+	seq   int
+	addrs []StreetAddress
+	end   int64
+	err   error
+}
+
+// Read source file line by line and call fn for every converted street address
+func ReadSourceFile(sourcefile string, fn func(addr StreetAddress) error) (err error) {
+	return ReadSourceFileContext(context.Background(), sourcefile, fn)
+}
+
+// Read source file in chunks, decode chunks in parallel on GOMAXPROCS workers and
+// call fn for every converted street address in source file order from the calling goroutine
+// Reading stops when ctx is cancelled or fn returns an error
+func ReadSourceFileContext(ctx context.Context, sourcefile string, fn func(addr StreetAddress) error) (err error) {
+	return ReadSourceFileCasing(ctx, sourcefile, LOWER, fn)
+}
+
+// Same as ReadSourceFileContext with names in casing
+func ReadSourceFileCasing(ctx context.Context, sourcefile string, casing Casing, fn func(addr StreetAddress) error) (err error) {
+	f, err := os.Open(sourcefile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fInfo, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	var sourceTotalSizeBytes = fInfo.Size()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := runtime.GOMAXPROCS(0)
+
+	chunks := make(chan rawChunk, workers)
+	decoded := make(chan decodedChunk, workers)
+
+	// Reader
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(chunks)
+		readErr <- readChunks(ctx, f, chunks)
+	}()
+
+	// Decoders
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			decodeChunks(ctx, chunks, decoded, casing)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(decoded)
+	}()
+
+	// Ticker for stats
+	ticker := time.NewTicker(time.Second * 1)
+	defer ticker.Stop()
+
+	// Aggregator, chunks are passed to fn in order
+	pending := make(map[int]decodedChunk)
+	next := 0
+
+	for chunk := range decoded {
+		if chunk.err != nil {
+			return chunk.err
+		}
+
+		pending[chunk.seq] = chunk
+
+		for {
+			c, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			for _, addr := range c.addrs {
+				err = fn(addr)
+				if err != nil {
+					return err
+				}
+			}
+
+			// Report stats
+			select {
+			case <-ticker.C:
+				percent := (float64(c.end) * float64(100.0)) / float64(sourceTotalSizeBytes)
+				log.Printf("%v / %v %07.3f%%", c.end, sourceTotalSizeBytes, percent)
+				var m runtime.MemStats
+				runtime.ReadMemStats(&m)
+				log.Printf(`%v %v`, bytesToHuman(m.Alloc), bytesToHuman(m.TotalAlloc))
+			default:
+
+			}
+		}
+	}
+
+	err = <-readErr
+	if err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+// Read source file in chunks of whole records
+func readChunks(ctx context.Context, r io.Reader, chunks chan<- rawChunk) error {
+	recordSize := rawRecordSize + 1 // Record and new line
+
+	var pos int64
+	var line int64
+
+	for seq := 0; ; seq++ {
+		buffer := make([]byte, recordSize*readChunkRecords)
+
+		n, err := io.ReadFull(r, buffer)
+		last := n < len(buffer)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+
+		buffer = buffer[:n]
+		records := n / recordSize
+
+		// Last record may be missing its new line
+		if n%recordSize == recordSize-1 {
+			buffer = append(buffer, '\n')
+			records++
+		} else if n%recordSize != 0 {
+			return &SourceError{line + int64(records) + 1, errors.New(`incomplete record`)}
+		}
+
+		for i := 0; i < records; i++ {
+			if buffer[(i+1)*recordSize-1] != '\n' {
+				return &SourceError{line + int64(i) + 1, errors.New("Not newline")}
+			}
+		}
+
+		pos += int64(n)
+		line += int64(records)
+
+		select {
+		case chunks <- rawChunk{seq: seq, data: buffer, records: records, end: pos}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// Decode chunks until chunks is closed or ctx is cancelled
+func decodeChunks(ctx context.Context, chunks <-chan rawChunk, decoded chan<- decodedChunk, casing Casing) {
+	decoder := NewRecordDecoderCasing(casing)
+	recordSize := rawRecordSize + 1
+
+	for chunk := range chunks {
+		result := decodedChunk{
+			seq:   chunk.seq,
+			addrs: make([]StreetAddress, 0, chunk.records),
+			end:   chunk.end,
+		}
+
+		for i := 0; i < chunk.records; i++ {
+			addr, err := decoder.Decode(chunk.data[i*recordSize : (i+1)*recordSize-1])
+			if err != nil {
+				// Every chunk but the last one is full
+				result.err = &SourceError{int64(chunk.seq)*readChunkRecords + int64(i) + 1, err}
+				break
+			}
+
+			addr.Line = int64(chunk.seq)*readChunkRecords + int64(i) + 1
+			result.addrs = append(result.addrs, addr)
+		}
+
+		select {
+		case decoded <- result:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
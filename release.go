@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/afero"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Release catalog file and latest release pointer in output directory
+const (
+	ReleaseCatalogFile = `releases.json`
+	LatestRelease      = `latest`
+)
+
+// Stored release in release catalog
+type ReleaseJSON struct {
+	SourceInfo
+	Directory string `json:"directory"` // Directory name under output directory
+	Generated string `json:"generated"` // Generation time, RFC 3339
+}
+
+// Write release to <outputdir>/<RunningDate>, point <outputdir>/latest to it,
+// add it to the release catalog and prune old releases
+func PublishRelease(fs *afero.Afero, outputdir string, info SourceInfo, options ConvertOptions) (err error) {
+	if len(info.RunningDate) != 8 {
+		return fmt.Errorf(`invalid running date '%s'`, info.RunningDate)
+	}
+
+	_, err = time.Parse(`20060102`, info.RunningDate)
+	if err != nil {
+		return fmt.Errorf(`invalid running date '%s': %v`, info.RunningDate, err)
+	}
+
+	err = os.MkdirAll(outputdir, os.FileMode(0700))
+	if err != nil {
+		return err
+	}
+
+	log.Printf(`Release: '%s'`, info.RunningDate)
+
+	err = PublishFiles(fs, filepath.Join(outputdir, info.RunningDate), options)
+	if err != nil {
+		return err
+	}
+
+	err = SetLatestRelease(outputdir, info.RunningDate)
+	if err != nil {
+		return err
+	}
+
+	releases, err := ReadReleaseCatalog(outputdir)
+	if err != nil {
+		return err
+	}
+
+	release := ReleaseJSON{
+		SourceInfo: info,
+		Directory:  info.RunningDate,
+		Generated:  time.Now().UTC().Format(time.RFC3339),
+	}
+
+	// Replace re-converted release
+	var kept []ReleaseJSON
+	for _, r := range releases {
+		if r.Directory != release.Directory {
+			kept = append(kept, r)
+		}
+	}
+	releases = append(kept, release)
+
+	releases, err = PruneReleases(outputdir, releases, info.RunningDate, options.KeepReleases, options.MaxReleaseAge)
+	if err != nil {
+		return err
+	}
+
+	return WriteReleaseCatalog(outputdir, releases)
+}
+
+// Atomically point <outputdir>/latest symlink to release directory
+func SetLatestRelease(outputdir string, release string) error {
+	// Relative link so that the output directory can be moved
+	return ReplaceSymlink(filepath.Join(outputdir, LatestRelease), release)
+}
+
+// Read release catalog, missing catalog is empty
+func ReadReleaseCatalog(outputdir string) (releases []ReleaseJSON, err error) {
+	b, err := ioutil.ReadFile(filepath.Join(outputdir, ReleaseCatalogFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	err = json.Unmarshal(b, &releases)
+	if err != nil {
+		return nil, err
+	}
+
+	return releases, nil
+}
+
+// Atomically write release catalog sorted by running date
+func WriteReleaseCatalog(outputdir string, releases []ReleaseJSON) error {
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Directory < releases[j].Directory
+	})
+
+	if releases == nil {
+		releases = []ReleaseJSON{}
+	}
+
+	b, err := json.MarshalIndent(releases, ``, `  `)
+	if err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(filepath.Join(outputdir, ReleaseCatalogFile), append(b, '\n'), os.FileMode(0600))
+}
+
+// Remove releases beyond keep newest ones or with running date older than maxAge
+// The current release is never removed
+func PruneReleases(outputdir string, releases []ReleaseJSON, current string, keep int, maxAge time.Duration) (kept []ReleaseJSON, err error) {
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Directory > releases[j].Directory
+	})
+
+	now := time.Now().UTC()
+
+	for idx, r := range releases {
+		prune := false
+
+		if keep > 0 && idx >= keep {
+			prune = true
+		}
+
+		if maxAge > 0 {
+			date, err := time.Parse(`20060102`, r.RunningDate)
+			if err == nil && now.Sub(date) > maxAge {
+				prune = true
+			}
+		}
+
+		if !prune || r.Directory == current {
+			kept = append(kept, r)
+			continue
+		}
+
+		if r.Directory == `` || r.Directory == LatestRelease || filepath.Base(r.Directory) != r.Directory {
+			return nil, errors.New(`invalid release directory in catalog: '` + r.Directory + `'`)
+		}
+
+		log.Printf(`Pruning release: '%s'`, r.Directory)
+
+		err = RemovePublished(filepath.Join(outputdir, r.Directory))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return kept, nil
+}
+
+// Write file to temporary file in the same directory and rename it in place
+func WriteFileAtomic(fname string, b []byte, perm os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(fname), `.`+filepath.Base(fname)+`.tmp-`)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	err = os.Rename(f.Name(), fname)
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/afero"
+	"sort"
+)
+
+type SchemaVersion string
+
+// Output schema versions
+const (
+	V1 SchemaVersion = "v1" // Original schema, "se" for Swedish and municipality.json as an array
+	V2 SchemaVersion = "v2" // "sv" for Swedish, municipality.json as an object and descriptive keys
+)
+
+// Parse schema version, empty is V1
+func ParseSchemaVersion(s string) (SchemaVersion, error) {
+	switch SchemaVersion(s) {
+	case ``:
+		return V1, nil
+	case V1, V2:
+		return SchemaVersion(s), nil
+	}
+
+	return ``, fmt.Errorf(`unknown schema '%s', expected v1 or v2`, s)
+}
+
+// Search keys and URL slugs of Finnish and Swedish names, only in schema v2
+type NameKeysV2JSON struct {
+	FiKey  string `json:"fikey,omitempty"`  // Search key of Finnish name
+	SvKey  string `json:"svkey,omitempty"`  // Search key of Swedish name
+	FiSlug string `json:"fislug,omitempty"` // ASCII slug of Finnish name
+	SvSlug string `json:"svslug,omitempty"` // ASCII slug of Swedish name
+}
+
+// Municipality in schema v2, municipality.json holds exactly one
+type MunicipalityV2JSON struct {
+	Code string `json:"code"`         // Municipality code
+	Fi   string `json:"fi,omitempty"` // Municipality name in Finnish
+	Sv   string `json:"sv,omitempty"` // Municipality name in Swedish
+	NameKeysV2JSON
+	Lines LineRanges `json:"lines,omitempty"` // Source lines, with provenance
+}
+
+// Postal code in schema v2
+type PostnumberV2JSON struct {
+	Code   string `json:"code"`             // Postal code
+	Fi     string `json:"fi,omitempty"`     // Postal code name in Finnish
+	Sv     string `json:"sv,omitempty"`     // Postal code name in Swedish
+	FiAbbr string `json:"fiabbr,omitempty"` // Abbreviated postal code name in Finnish
+	SvAbbr string `json:"svabbr,omitempty"` // Abbreviated postal code name in Swedish
+	NameKeysV2JSON
+	Lines LineRanges `json:"lines,omitempty"` // Source lines, with provenance
+}
+
+// Street in schema v2
+type StreetV2JSON struct {
+	PostalCode string `json:"postal,omitempty"` // Postal code when the file has streets of several postal codes
+	Fi         string `json:"fi,omitempty"`     // Street name in Finnish
+	Sv         string `json:"sv,omitempty"`     // Street name in Swedish
+	Min        int64  `json:"min,omitempty"`    // Minimum number
+	Max        int64  `json:"max,omitempty"`    // Maximum number
+	NameKeysV2JSON
+	Lines LineRanges `json:"lines,omitempty"` // Source lines, with provenance
+}
+
+// Entry of /index.json in schema v2
+type MunicipalityIndexV2JSON struct {
+	MunicipalityV2JSON
+	PostalCodes int `json:"postalcodes"` // Number of postal codes
+	Streets     int `json:"streets"`     // Number of streets in all postal codes
+}
+
+// Entry of /<MunicipalityCode>/index.json in schema v2
+type PostalCodeIndexV2JSON struct {
+	Code    string `json:"code"`         // Postal code
+	Fi      string `json:"fi,omitempty"` // Postal code name in Finnish
+	Sv      string `json:"sv,omitempty"` // Postal code name in Swedish
+	Streets int    `json:"streets"`      // Number of streets
+	NameKeysV2JSON
+}
+
+func (m MunicipalityIndexJSON) v2() MunicipalityIndexV2JSON {
+	return MunicipalityIndexV2JSON{
+		MunicipalityV2JSON: MunicipalityV2JSON{
+			Code:           m.Code,
+			Fi:             m.Fi,
+			Sv:             m.Se,
+			NameKeysV2JSON: SearchKeysFor(m.Fi, m.Se),
+		},
+		PostalCodes: m.PostalCodes,
+		Streets:     m.Streets,
+	}
+}
+
+func (p PostalCodeIndexJSON) v2() PostalCodeIndexV2JSON {
+	return PostalCodeIndexV2JSON{
+		Code:           p.Code,
+		Fi:             p.Fi,
+		Sv:             p.Se,
+		Streets:        p.Streets,
+		NameKeysV2JSON: SearchKeysFor(p.Fi, p.Se),
+	}
+}
+
+// Add street address to schema v2 file of kind
+func convertRecordV2(fs *afero.Afero, kind OutputKind, fName string, postal string, addr StreetAddress) error {
+	switch kind {
+	case MUNICIPALITY:
+		return ConvertMunicipalityV2(fs, fName, addr)
+	case POSTNUMBER:
+		return ConvertPostalCodeV2(fs, fName, addr)
+	case STREET:
+		return ConvertStreetV2(fs, fName, postal, addr)
+	}
+
+	return nil
+}
+
+// Add municipality to schema v2 municipality file
+// The first names of a municipality code are kept, a file can't hold two municipalities.
+func ConvertMunicipalityV2(fs *afero.Afero, fName string, addr StreetAddress) error {
+	// ConvertFromFile would start a new file with an empty array
+	exists, err := fs.Exists(fName)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		var data MunicipalityV2JSON
+		err = ConvertFromFile(fName, fs, &data)
+		if err != nil {
+			return err
+		}
+
+		if data.Code != addr.MunicipalityCode {
+			return fmt.Errorf(`schema v2 file '%s' would hold municipalities %s and %s, layout must contain {municipality}`, fName, data.Code, addr.MunicipalityCode)
+		}
+
+		if addr.Line == 0 {
+			return nil
+		}
+
+		data.Lines = data.Lines.Add(addr.Line)
+		return SaveData(fs, fName, data)
+	}
+
+	data := MunicipalityV2JSON{
+		Code: addr.MunicipalityCode,
+		Fi:   addr.MunicipalityNameFi,
+		Sv:   addr.MunicipalityNameSe,
+
+		NameKeysV2JSON: SearchKeysFor(addr.MunicipalityNameFi, addr.MunicipalityNameSe),
+		Lines:          LineRanges{}.Add(addr.Line),
+	}
+
+	return SaveData(fs, fName, data)
+}
+
+// Add postal code to schema v2 postal code file, one entry per postal code and Finnish name
+func ConvertPostalCodeV2(fs *afero.Afero, fName string, addr StreetAddress) error {
+	var data []PostnumberV2JSON
+
+	err := ConvertFromFile(fName, fs, &data)
+	if err != nil {
+		return err
+	}
+
+	for idx, k := range data {
+		if k.Code == addr.PostalCode && k.Fi == addr.PostalCodeNameFi {
+			if addr.Line == 0 {
+				return nil
+			}
+
+			data[idx].Lines = k.Lines.Add(addr.Line)
+			return SaveData(fs, fName, data)
+		}
+	}
+
+	data = append(data, PostnumberV2JSON{
+		Code:   addr.PostalCode,
+		Fi:     addr.PostalCodeNameFi,
+		Sv:     addr.PostalCodeNameSe,
+		FiAbbr: addr.PostalCodeShortNameFi,
+		SvAbbr: addr.PostalCodeShortNameSe,
+
+		NameKeysV2JSON: SearchKeysFor(addr.PostalCodeNameFi, addr.PostalCodeNameSe),
+		Lines:          LineRanges{}.Add(addr.Line),
+	})
+
+	return SaveData(fs, fName, data)
+}
+
+// Add street to schema v2 street file, entries are merged by postal code and Finnish name like in v1
+func ConvertStreetV2(fs *afero.Afero, fName string, postal string, addr StreetAddress) error {
+	if addr.StreetNameFi == `` {
+		return nil
+	}
+
+	var data []StreetV2JSON
+
+	err := ConvertFromFile(fName, fs, &data)
+	if err != nil {
+		return err
+	}
+
+	var found = false
+	for idx, k := range data {
+		if k.PostalCode == postal && k.Fi == addr.StreetNameFi {
+			data[idx].Min, data[idx].Max = addr.StreetNumberMinMax([]int64{k.Min, k.Max})
+			data[idx].Lines = k.Lines.Add(addr.Line)
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		min, max := addr.StreetNumberMinMax([]int64{})
+		data = append(data, StreetV2JSON{
+			PostalCode: postal,
+			Fi:         addr.StreetNameFi,
+			Sv:         addr.StreetNameSe,
+			Min:        min,
+			Max:        max,
+
+			NameKeysV2JSON: SearchKeysFor(addr.StreetNameFi, addr.StreetNameSe),
+			Lines:          LineRanges{}.Add(addr.Line),
+		})
+	}
+
+	return SaveData(fs, fName, data)
+}
+
+// Sort schema v2 file entries like SortFiles, postal codes are sorted by code first
+func sortFileV2(fs *afero.Afero, fName string, kind OutputKind, lang string) (err error) {
+	switch kind {
+	case POSTNUMBER:
+		var data []PostnumberV2JSON
+		err = ConvertFromFile(fName, fs, &data)
+		if err != nil {
+			return err
+		}
+
+		sort.SliceStable(data, func(i, j int) bool {
+			if data[i].Code != data[j].Code {
+				return data[i].Code < data[j].Code
+			}
+
+			return lessNames(lang, data[i].Fi, data[i].Sv, data[j].Fi, data[j].Sv)
+		})
+
+		return SaveData(fs, fName, data)
+
+	case STREET:
+		var data []StreetV2JSON
+		err = ConvertFromFile(fName, fs, &data)
+		if err != nil {
+			return err
+		}
+
+		sort.SliceStable(data, func(i, j int) bool {
+			if data[i].PostalCode != data[j].PostalCode {
+				return data[i].PostalCode < data[j].PostalCode
+			}
+
+			return lessNames(lang, data[i].Fi, data[i].Sv, data[j].Fi, data[j].Sv)
+		})
+
+		return SaveData(fs, fName, data)
+	}
+
+	// Municipality file has one entry
+	return nil
+}
+
+// Names of municipality file in schema, nothing if the file is empty
+func readMunicipalityNames(fs *afero.Afero, fName string, schema SchemaVersion) (names MunicipalityJSON, err error) {
+	if schema != V2 {
+		var data []MunicipalityJSON
+		err = ConvertFromFile(fName, fs, &data)
+		if err != nil || len(data) == 0 {
+			return names, err
+		}
+
+		return data[0], nil
+	}
+
+	var data MunicipalityV2JSON
+	err = ConvertFromFile(fName, fs, &data)
+
+	return MunicipalityJSON{
+		Fi: data.Fi,
+		Se: data.Sv,
+	}, err
+}
+
+// First names of postal code file in schema, nothing if the file is empty
+func readPostalCodeNames(fs *afero.Afero, fName string, schema SchemaVersion) (names PostnumberJSON, err error) {
+	if schema != V2 {
+		var data []PostnumberJSON
+		err = ConvertFromFile(fName, fs, &data)
+		if err != nil || len(data) == 0 {
+			return names, err
+		}
+
+		return data[0], nil
+	}
+
+	var data []PostnumberV2JSON
+	err = ConvertFromFile(fName, fs, &data)
+	if err != nil || len(data) == 0 {
+		return names, err
+	}
+
+	return PostnumberJSON{
+		Fi:    data[0].Fi,
+		Se:    data[0].Sv,
+		FiLyh: data[0].FiAbbr,
+		SeLyh: data[0].SvAbbr,
+	}, nil
+}
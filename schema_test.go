@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"github.com/spf13/afero"
+	"testing"
+)
+
+// Search keys and slugs are only written in schema v2, v1 output stays as it was
+func TestSearchKeysOnlyInV2(t *testing.T) {
+	addr := StreetAddress{
+		PostalCode:         `00320`,
+		PostalCodeNameFi:   `helsinki`,
+		PostalCodeNameSe:   `helsingfors`,
+		StreetNameFi:       `etelä-haaga`,
+		StreetNameSe:       `södra haga`,
+		MunicipalityCode:   `091`,
+		MunicipalityNameFi: `helsinki`,
+		MunicipalityNameSe: `helsingfors`,
+	}
+
+	for _, schema := range []SchemaVersion{V1, V2} {
+		fs := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+		var err error
+		if schema == V2 {
+			err = ConvertMunicipalityV2(fs, `/091/municipality.json`, addr)
+			if err == nil {
+				err = ConvertPostalCodeV2(fs, `/091/00320/postnumber.json`, addr)
+			}
+			if err == nil {
+				err = ConvertStreetV2(fs, `/091/00320/street.json`, ``, addr)
+			}
+		} else {
+			err = ConvertMunicipality(fs, `/091/municipality.json`, addr)
+			if err == nil {
+				err = ConvertPostalCode(fs, `/091/00320/postnumber.json`, addr)
+			}
+			if err == nil {
+				err = ConvertStreet(fs, `/091/00320/street.json`, ``, addr)
+			}
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		files, err := GenerateIndexes(fs, schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		files = append(files, `/091/municipality.json`, `/091/00320/postnumber.json`, `/091/00320/street.json`)
+
+		for _, fName := range files {
+			b, err := fs.ReadFile(fName)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hasKeys := bytes.Contains(b, []byte(`"fikey"`)) && bytes.Contains(b, []byte(`"fislug"`))
+			if hasKeys != (schema == V2) {
+				t.Errorf(`%s %s: search keys written %v, expected %v: %s`, schema, fName, hasKeys, schema == V2, b)
+			}
+
+			if bytes.Contains(b, []byte(`"sekey"`)) || bytes.Contains(b, []byte(`"seslug"`)) {
+				t.Errorf(`%s %s: v1 search key names: %s`, schema, fName, b)
+			}
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"github.com/spf13/afero"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+)
+
+// Rough peak memory of in-memory conversion per source file byte
+const spillMemoryFactor = 4
+
+// Upper limit of partitions, every partition keeps a spill file open while reading
+const spillMaxPartitions = 256
+
+// Rough memory of a distinct street key counted while spilling, key and map overhead
+const spillCounterKeySize = 96
+
+// Record in spill file
+type spillRecord struct {
+	Layout int // Index in layouts
+	Addr   StreetAddress
+}
+
+type spillPartition struct {
+	f   *os.File
+	w   *bufio.Writer
+	enc *gob.Encoder
+}
+
+// Number of partitions needed to stay under maxMemory bytes, capped is true if more than spillMaxPartitions would be needed
+func spillPartitions(sourceSize int64, maxMemory int64) (partitions int, capped bool) {
+	needed := (sourceSize*spillMemoryFactor + maxMemory - 1) / maxMemory
+
+	if needed < 1 {
+		return 1, false
+	} else if needed > spillMaxPartitions {
+		return spillMaxPartitions, true
+	}
+
+	return int(needed), false
+}
+
+// Partition of an output file
+func spillPartitionOf(fName string, partitions int) int {
+	h := fnv.New32a()
+	h.Write([]byte(fName))
+	return int(h.Sum32() % uint32(partitions))
+}
+
+// Convert source file one partition at a time to bound memory use, generated files are written to fs
+// Records are first spilled to temporary files partitioned by output file path, so every output file is
+// generated from one partition with records in source order. The output is the same as with in-memory conversion.
+func ConvertPartitioned(ctx context.Context, sourcefile string, fs *afero.Afero, layouts []Layout, info *SourceInfo, options ConvertOptions) (err error) {
+	fInfo, err := os.Stat(sourcefile)
+	if err != nil {
+		return err
+	}
+
+	spilldir, err := ioutil.TempDir(``, `FinnishStreetDatabaseConverter-spill-`)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(spilldir)
+
+	count, capped := spillPartitions(fInfo.Size(), options.MaxMemory)
+	if capped {
+		log.Printf(`Warning: memory limit of %d MiB needs more than %d partitions, peak memory will be higher`, options.MaxMemory/1024/1024, spillMaxPartitions)
+	}
+
+	partitions := make([]spillPartition, count)
+
+	for idx := range partitions {
+		f, err := os.Create(filepath.Join(spilldir, strconv.Itoa(idx)))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		w := bufio.NewWriter(f)
+		partitions[idx] = spillPartition{
+			f:   f,
+			w:   w,
+			enc: gob.NewEncoder(w),
+		}
+	}
+
+	log.Printf(`Spilling to %d partitions..`, len(partitions))
+
+	counter := newSourceCounter()
+
+	err = ReadSourceFileCasing(ctx, sourcefile, options.casing(), func(streetAddr StreetAddress) error {
+		if !options.Filter.Match(streetAddr) {
+			info.Skipped++
+			return nil
+		}
+
+		counter.Add(info, streetAddr)
+
+		for idx, layout := range layouts {
+			p := partitions[spillPartitionOf(layout.Path(streetAddr), len(partitions))]
+
+			err := p.enc.Encode(spillRecord{
+				Layout: idx,
+				Addr:   streetAddr,
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Street keys are counted in memory over the whole source file
+	if counterSize := int64(len(counter.streets)) * spillCounterKeySize; counterSize > options.MaxMemory {
+		log.Printf(`Warning: counting %d streets takes about %d MiB, more than the memory limit`, len(counter.streets), counterSize/1024/1024)
+	}
+
+	for _, p := range partitions {
+		err = p.w.Flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	for idx, p := range partitions {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		log.Printf(`Converting partition %d / %d..`, idx+1, len(partitions))
+
+		err = convertPartition(p.f, fs, layouts, options)
+		if err != nil {
+			return err
+		}
+
+		// Release spill file space early
+		p.f.Close()
+		err = os.Remove(p.f.Name())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Convert records of one spill file in memory and copy the generated files to fs
+func convertPartition(f *os.File, fs *afero.Afero, layouts []Layout, options ConvertOptions) (err error) {
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	memFs := &afero.Afero{
+		Fs: afero.NewMemMapFs(),
+	}
+
+	kinds := make(map[string]OutputKind)
+
+	dec := gob.NewDecoder(bufio.NewReader(f))
+
+	for {
+		var rec spillRecord
+
+		err = dec.Decode(&rec)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			return err
+		}
+
+		err = ConvertRecord(memFs, layouts[rec.Layout:rec.Layout+1], kinds, options, rec.Addr)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = FinishFiles(memFs, kinds, options)
+	if err != nil {
+		return err
+	}
+
+	for fName := range kinds {
+		b, err := memFs.ReadFile(fName)
+		if err != nil {
+			return err
+		}
+
+		err = fs.MkdirAll(path.Dir(fName), os.FileMode(0700))
+		if err != nil {
+			return err
+		}
+
+		err = fs.WriteFile(fName, b, os.FileMode(0600))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+)
+
+// Number of streets listed in LargestBuildingNumbers
+const statsLargestBuildingNumbers = 10
+
+type MunicipalityStatsJSON struct {
+	Code        string `json:"code"`         // Municipality code
+	Fi          string `json:"fi,omitempty"` // Municipality name in Finnish
+	Se          string `json:"se,omitempty"` // Municipality name in Swedish
+	Records     int    `json:"records"`      // Number of lines
+	PostalCodes int    `json:"postalcodes"`  // Number of distinct postal codes
+	Streets     int    `json:"streets"`      // Number of distinct streets per postal code
+}
+
+type SwedishNamesJSON struct {
+	PostalCodeName      int `json:"postalcodename"`      // Records with Swedish postal code name
+	PostalCodeShortName int `json:"postalcodeshortname"` // Records with Swedish postal code name abbreviation
+	StreetName          int `json:"streetname"`          // Records with Swedish street name
+	MunicipalityName    int `json:"municipalityname"`    // Records with Swedish municipality name
+}
+
+type SharedPostalCodeJSON struct {
+	Code           string   `json:"code"`           // Postal code
+	Municipalities []string `json:"municipalities"` // Municipality codes, sorted
+}
+
+type BuildingNumberJSON struct {
+	PostalCode string `json:"postal"`
+	Street     string `json:"street"` // Street name in Finnish
+	Max        int64  `json:"max"`    // Largest building number
+}
+
+// Dataset statistics of source file
+type DatasetStatsJSON struct {
+	Source                 SourceInfo              `json:"source"`
+	Bilingual              int                     `json:"bilingual"`              // Records with both Finnish and Swedish street name
+	BilingualShare         float64                 `json:"bilingualshare"`         // Bilingual / records, 0-1
+	SwedishNames           SwedishNamesJSON        `json:"swedishnames"`           // Records with non-empty Swedish fields
+	StreetsWithoutNumbers  int                     `json:"streetswithoutnumbers"`  // Streets without any building numbers
+	Municipalities         []MunicipalityStatsJSON `json:"municipalities"`         // Sorted by code
+	SharedPostalCodes      []SharedPostalCodeJSON  `json:"sharedpostalcodes"`      // Postal codes in several municipalities, sorted by code
+	LargestBuildingNumbers []BuildingNumberJSON    `json:"largestbuildingnumbers"` // Streets with largest building numbers, largest first
+}
+
+type streetStats struct {
+	postalCode string
+	name       string
+	max        int64 // 0 if no numbers
+}
+
+// Read source file and collect statistics of records matching filter
+func DatasetStats(ctx context.Context, sourcefile string, filter Filter) (stats DatasetStatsJSON, err error) {
+	stats.Source.FileName = filepath.Base(sourcefile)
+	stats.Source.SHA256, err = FileSHA256(sourcefile)
+	if err != nil {
+		return stats, err
+	}
+
+	counter := newSourceCounter()
+	municipalities := make(map[string]*MunicipalityStatsJSON)
+	municipalityPostalCodes := make(map[string]bool)      // Municipality code and postal code
+	postalCodeMunicipalities := make(map[string][]string) // Postal code -> municipality codes
+	streets := make(map[string]*streetStats)              // Postal code and Finnish street name
+
+	err = ReadSourceFileContext(ctx, sourcefile, func(addr StreetAddress) error {
+		if !filter.Match(addr) {
+			stats.Source.Skipped++
+			return nil
+		}
+
+		counter.Add(&stats.Source, addr)
+
+		if addr.StreetNameFi != `` && addr.StreetNameSe != `` {
+			stats.Bilingual++
+		}
+
+		if addr.PostalCodeNameSe != `` {
+			stats.SwedishNames.PostalCodeName++
+		}
+		if addr.PostalCodeShortNameSe != `` {
+			stats.SwedishNames.PostalCodeShortName++
+		}
+		if addr.StreetNameSe != `` {
+			stats.SwedishNames.StreetName++
+		}
+		if addr.MunicipalityNameSe != `` {
+			stats.SwedishNames.MunicipalityName++
+		}
+
+		m, ok := municipalities[addr.MunicipalityCode]
+		if !ok {
+			m = &MunicipalityStatsJSON{
+				Code: addr.MunicipalityCode,
+				Fi:   addr.MunicipalityNameFi,
+				Se:   addr.MunicipalityNameSe,
+			}
+			municipalities[addr.MunicipalityCode] = m
+		}
+		m.Records++
+
+		if key := addr.MunicipalityCode + `/` + addr.PostalCode; !municipalityPostalCodes[key] {
+			municipalityPostalCodes[key] = true
+			m.PostalCodes++
+			postalCodeMunicipalities[addr.PostalCode] = append(postalCodeMunicipalities[addr.PostalCode], addr.MunicipalityCode)
+		}
+
+		if addr.StreetNameFi == `` {
+			return nil
+		}
+
+		key := addr.PostalCode + `/` + addr.StreetNameFi
+		s, ok := streets[key]
+		if !ok {
+			s = &streetStats{
+				postalCode: addr.PostalCode,
+				name:       addr.StreetNameFi,
+			}
+			streets[key] = s
+			m.Streets++
+		}
+
+		_, max := addr.StreetNumberMinMax([]int64{s.max})
+		s.max = max
+
+		return nil
+	})
+
+	if err != nil {
+		return stats, err
+	}
+
+	if stats.Source.Records > 0 {
+		stats.BilingualShare = float64(stats.Bilingual) / float64(stats.Source.Records)
+	}
+
+	stats.Municipalities = []MunicipalityStatsJSON{}
+	for _, m := range municipalities {
+		stats.Municipalities = append(stats.Municipalities, *m)
+	}
+
+	sort.Slice(stats.Municipalities, func(i, j int) bool {
+		return stats.Municipalities[i].Code < stats.Municipalities[j].Code
+	})
+
+	stats.SharedPostalCodes = []SharedPostalCodeJSON{}
+	for code, codes := range postalCodeMunicipalities {
+		if len(codes) < 2 {
+			continue
+		}
+
+		sort.Strings(codes)
+		stats.SharedPostalCodes = append(stats.SharedPostalCodes, SharedPostalCodeJSON{
+			Code:           code,
+			Municipalities: codes,
+		})
+	}
+
+	sort.Slice(stats.SharedPostalCodes, func(i, j int) bool {
+		return stats.SharedPostalCodes[i].Code < stats.SharedPostalCodes[j].Code
+	})
+
+	var numbered []*streetStats
+	for _, s := range streets {
+		if s.max <= 0 {
+			stats.StreetsWithoutNumbers++
+			continue
+		}
+
+		numbered = append(numbered, s)
+	}
+
+	sort.Slice(numbered, func(i, j int) bool {
+		a, b := numbered[i], numbered[j]
+		if a.max != b.max {
+			return a.max > b.max
+		}
+
+		if a.postalCode != b.postalCode {
+			return a.postalCode < b.postalCode
+		}
+
+		return FinnishCollator.Less(a.name, b.name)
+	})
+
+	stats.LargestBuildingNumbers = []BuildingNumberJSON{}
+	for i := 0; i < len(numbered) && i < statsLargestBuildingNumbers; i++ {
+		stats.LargestBuildingNumbers = append(stats.LargestBuildingNumbers, BuildingNumberJSON{
+			PostalCode: numbered[i].postalCode,
+			Street:     numbered[i].name,
+			Max:        numbered[i].max,
+		})
+	}
+
+	return stats, nil
+}
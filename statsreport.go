@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Write statistics as plain text tables
+func WriteStatsText(w io.Writer, stats DatasetStatsJSON) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "File:\t%s\n", stats.Source.FileName)
+	fmt.Fprintf(tw, "SHA-256:\t%s\n", stats.Source.SHA256)
+	fmt.Fprintf(tw, "Running date:\t%s\n", stats.Source.RunningDate)
+	fmt.Fprintf(tw, "Records:\t%d\n", stats.Source.Records)
+	if stats.Source.Skipped > 0 {
+		fmt.Fprintf(tw, "Skipped:\t%d\n", stats.Source.Skipped)
+	}
+	fmt.Fprintf(tw, "Municipalities:\t%d\n", stats.Source.Municipalities)
+	fmt.Fprintf(tw, "Postal codes:\t%d\n", stats.Source.PostalCodes)
+	fmt.Fprintf(tw, "Streets:\t%d\n", stats.Source.Streets)
+	fmt.Fprintf(tw, "Streets without building numbers:\t%d\n", stats.StreetsWithoutNumbers)
+	fmt.Fprintf(tw, "Bilingual records:\t%d (%.1f%%)\n", stats.Bilingual, stats.BilingualShare*100)
+	fmt.Fprintf(tw, "Swedish postal code names:\t%d\n", stats.SwedishNames.PostalCodeName)
+	fmt.Fprintf(tw, "Swedish postal code abbreviations:\t%d\n", stats.SwedishNames.PostalCodeShortName)
+	fmt.Fprintf(tw, "Swedish street names:\t%d\n", stats.SwedishNames.StreetName)
+	fmt.Fprintf(tw, "Swedish municipality names:\t%d\n", stats.SwedishNames.MunicipalityName)
+
+	fmt.Fprintf(tw, "\nMunicipality\tFinnish\tSwedish\tRecords\tPostal codes\tStreets\n")
+	for _, m := range stats.Municipalities {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\n", m.Code, m.Fi, m.Se, m.Records, m.PostalCodes, m.Streets)
+	}
+
+	fmt.Fprintf(tw, "\nPostal code\tMunicipalities\n")
+	for _, p := range stats.SharedPostalCodes {
+		fmt.Fprintf(tw, "%s\t%s\n", p.Code, strings.Join(p.Municipalities, ` `))
+	}
+
+	fmt.Fprintf(tw, "\nPostal code\tStreet\tLargest number\n")
+	for _, b := range stats.LargestBuildingNumbers {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", b.PostalCode, b.Street, b.Max)
+	}
+
+	return tw.Flush()
+}
+
+var statsHTMLTemplate = template.Must(template.New(`stats`).Funcs(template.FuncMap{
+	`percent`: func(f float64) string {
+		return fmt.Sprintf(`%.1f%%`, f*100)
+	},
+	`join`: strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Source.FileName}} statistics</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+th { background: #eee; }
+td.n { text-align: right; font-variant-numeric: tabular-nums; }
+</style>
+</head>
+<body>
+<h1>{{.Source.FileName}}</h1>
+<table>
+<tr><th>SHA-256</th><td>{{.Source.SHA256}}</td></tr>
+<tr><th>Running date</th><td>{{.Source.RunningDate}}</td></tr>
+<tr><th>Records</th><td class="n">{{.Source.Records}}</td></tr>
+{{- if .Source.Skipped}}
+<tr><th>Skipped</th><td class="n">{{.Source.Skipped}}</td></tr>
+{{- end}}
+<tr><th>Municipalities</th><td class="n">{{.Source.Municipalities}}</td></tr>
+<tr><th>Postal codes</th><td class="n">{{.Source.PostalCodes}}</td></tr>
+<tr><th>Streets</th><td class="n">{{.Source.Streets}}</td></tr>
+<tr><th>Streets without building numbers</th><td class="n">{{.StreetsWithoutNumbers}}</td></tr>
+<tr><th>Bilingual records</th><td class="n">{{.Bilingual}} ({{percent .BilingualShare}})</td></tr>
+<tr><th>Swedish postal code names</th><td class="n">{{.SwedishNames.PostalCodeName}}</td></tr>
+<tr><th>Swedish postal code abbreviations</th><td class="n">{{.SwedishNames.PostalCodeShortName}}</td></tr>
+<tr><th>Swedish street names</th><td class="n">{{.SwedishNames.StreetName}}</td></tr>
+<tr><th>Swedish municipality names</th><td class="n">{{.SwedishNames.MunicipalityName}}</td></tr>
+</table>
+<h2>Municipalities</h2>
+<table>
+<tr><th>Code</th><th>Finnish</th><th>Swedish</th><th>Records</th><th>Postal codes</th><th>Streets</th></tr>
+{{- range .Municipalities}}
+<tr><td>{{.Code}}</td><td>{{.Fi}}</td><td>{{.Se}}</td><td class="n">{{.Records}}</td><td class="n">{{.PostalCodes}}</td><td class="n">{{.Streets}}</td></tr>
+{{- end}}
+</table>
+<h2>Postal codes in several municipalities</h2>
+<table>
+<tr><th>Postal code</th><th>Municipalities</th></tr>
+{{- range .SharedPostalCodes}}
+<tr><td>{{.Code}}</td><td>{{join .Municipalities " "}}</td></tr>
+{{- end}}
+</table>
+<h2>Largest building numbers</h2>
+<table>
+<tr><th>Postal code</th><th>Street</th><th>Largest number</th></tr>
+{{- range .LargestBuildingNumbers}}
+<tr><td>{{.PostalCode}}</td><td>{{.Street}}</td><td class="n">{{.Max}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// Write statistics as a self-contained HTML page without external assets
+func WriteStatsHTML(w io.Writer, stats DatasetStatsJSON) error {
+	return statsHTMLTemplate.Execute(w, stats)
+}
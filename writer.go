@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Record identifier of street records
+const StreetRecordIdentifier = `KATUN`
+
+// Writes records in BAF fixed-width ISO-8859-1 format, each followed by new line
+type BAFWriter struct {
+	w *bufio.Writer
+}
+
+func NewBAFWriter(w io.Writer) *BAFWriter {
+	return &BAFWriter{
+		w: bufio.NewWriter(w),
+	}
+}
+
+// Write raw record as is
+func (bw *BAFWriter) WriteRaw(raw RawLineStructure) error {
+	record, err := MarshalFixed(raw)
+	if err != nil {
+		return err
+	}
+
+	_, err = bw.w.Write(append(record, '\n'))
+	return err
+}
+
+// Write street address, see StreetAddress.ToRaw
+func (bw *BAFWriter) WriteStreet(addr StreetAddress) error {
+	raw, err := addr.ToRaw()
+	if err != nil {
+		return err
+	}
+
+	return bw.WriteRaw(raw)
+}
+
+// Write buffered data to underlying writer
+func (bw *BAFWriter) Flush() error {
+	return bw.w.Flush()
+}
+
+// Convert street address back to raw record
+// Names are written in upper case like in Posti's files and missing building numbers as blanks.
+// A record with mixed case names, or decoded with another casing, doesn't come back byte for byte.
+func (src StreetAddress) ToRaw() (raw RawLineStructure, err error) {
+	fields := []struct {
+		name  string
+		dst   []byte
+		value string
+		upper bool
+	}{
+		{`RecordIdentifier`, raw.RecordIdentifier[:], StreetRecordIdentifier, false},                       // 1
+		{`RunningDate`, raw.RunningDate[:], src.RunningDate, false},                                        // 2
+		{`PostalCode`, raw.PostalCode[:], src.PostalCode, false},                                           // 3
+		{`PostalCodeNameFi`, raw.PostalCodeNameFi[:], src.PostalCodeNameFi, true},                          // 4
+		{`PostalCodeNameSe`, raw.PostalCodeNameSe[:], src.PostalCodeNameSe, true},                          // 5
+		{`PostalCodeShortNameFi`, raw.PostalCodeShortNameFi[:], src.PostalCodeShortNameFi, true},           // 6
+		{`PostalCodeShortNameSe`, raw.PostalCodeShortNameSe[:], src.PostalCodeShortNameSe, true},           // 7
+		{`StreetNameFi`, raw.StreetNameFi[:], src.StreetNameFi, true},                                      // 8
+		{`StreetNameSe`, raw.StreetNameSe[:], src.StreetNameSe, true},                                      // 9
+		{`Blank1`, raw.Blank1[:], ``, false},                                                               // 10
+		{`Blank2`, raw.Blank2[:], ``, false},                                                               // 11
+		{`BuildingDataType`, raw.BuildingDataType[:], evenOddToString(src.BuildingDataTypeEvenOdd), false}, // 12
+
+		{`SmallestBuildingNumber1`, raw.SmallestBuildingNumber1[:], numberToString(src.SmallestBuilding.BuildingNumber1), false},                       // 14
+		{`SmallestBuildingDeliveryLetter1`, raw.SmallestBuildingDeliveryLetter1[:], byteToString(src.SmallestBuilding.BuildingDeliveryLetter1), false}, // 15
+		{`SmallestPunctuationMark`, raw.SmallestPunctuationMark[:], byteToString(src.SmallestBuilding.PunctuationMark), false},                         // 16
+		{`SmallestBuildingNumber2`, raw.SmallestBuildingNumber2[:], numberToString(src.SmallestBuilding.BuildingNumber2), false},                       // 17
+		{`SmallestBuildingDeliveryLetter2`, raw.SmallestBuildingDeliveryLetter2[:], byteToString(src.SmallestBuilding.BuildingDeliveryLetter2), false}, // 18
+
+		{`HighestBuildingNumber1`, raw.HighestBuildingNumber1[:], numberToString(src.HighestBuilding.BuildingNumber1), false},                       // 20
+		{`HighestBuildingDeliveryLetter1`, raw.HighestBuildingDeliveryLetter1[:], byteToString(src.HighestBuilding.BuildingDeliveryLetter1), false}, // 21
+		{`HighestPunctuationMark`, raw.HighestPunctuationMark[:], byteToString(src.HighestBuilding.PunctuationMark), false},                         // 22
+		{`HighestBuildingNumber2`, raw.HighestBuildingNumber2[:], numberToString(src.HighestBuilding.BuildingNumber2), false},                       // 23
+		{`HighestBuildingDeliveryLetter2`, raw.HighestBuildingDeliveryLetter2[:], byteToString(src.HighestBuilding.BuildingDeliveryLetter2), false}, // 24
+
+		{`MunicipalityCode`, raw.MunicipalityCode[:], src.MunicipalityCode, false},      // 25
+		{`MunicipalityNameFi`, raw.MunicipalityNameFi[:], src.MunicipalityNameFi, true}, // 26
+		{`MunicipalityNameSe`, raw.MunicipalityNameSe[:], src.MunicipalityNameSe, true}, // 27
+	}
+
+	for _, f := range fields {
+		b, err := utf8ToLatin1(f.value)
+		if err != nil {
+			return raw, fmt.Errorf(`%s: %v`, f.name, err)
+		}
+
+		if len(b) > len(f.dst) {
+			return raw, fmt.Errorf(`%s: value '%s' longer than %d bytes`, f.name, f.value, len(f.dst))
+		}
+
+		if f.upper {
+			for i, c := range b {
+				b[i] = latin1ToUpper(c)
+			}
+		}
+
+		n := copy(f.dst, b)
+		for i := n; i < len(f.dst); i++ {
+			f.dst[i] = ' '
+		}
+	}
+
+	return raw, nil
+}
+
+// a-z and à-þ except ÷
+func latin1ToUpper(c byte) byte {
+	if (c >= 'a' && c <= 'z') || (c >= 0xE0 && c <= 0xFE && c != 0xF7) {
+		return c - ('a' - 'A')
+	}
+
+	return c
+}
+
+func evenOddToString(eo EvenOdd) string {
+	switch eo {
+	case ODD:
+		return `1`
+	case EVEN:
+		return `2`
+	}
+
+	return ``
+}
+
+// Missing number -1 is blank
+func numberToString(n int64) string {
+	if n < 0 {
+		return ``
+	}
+
+	return strconv.FormatInt(n, 10)
+}
+
+// Missing letter 0 is blank
+func byteToString(b byte) string {
+	if b == 0 {
+		return ``
+	}
+
+	return string(rune(b))
+}
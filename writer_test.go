@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// Generated records as source file content, the last line has no new line
+func testSourceFile(n int) []byte {
+	return bytes.Join(testRecords(n), []byte{'\n'})
+}
+
+// Read records of source file content with the source file reader, fails if the last line wasn't read
+func readTestRecords(t *testing.T, source []byte) (records [][]byte) {
+	chunks := make(chan rawChunk)
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- readChunks(context.Background(), bytes.NewReader(source), chunks)
+		close(chunks)
+	}()
+
+	recordSize := rawRecordSize + 1
+
+	for chunk := range chunks {
+		for i := 0; i < chunk.records; i++ {
+			records = append(records, chunk.data[i*recordSize:i*recordSize+rawRecordSize])
+		}
+	}
+
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (len(source) + 1) / recordSize; len(records) != want {
+		t.Fatalf(`read %d records, expected %d`, len(records), want)
+	}
+
+	return records
+}
+
+func TestUnmarshalMarshalFixedRoundTrip(t *testing.T) {
+	for idx, record := range readTestRecords(t, testSourceFile(500)) {
+		var raw RawLineStructure
+		err := UnmarshalFixed(record, &raw)
+		if err != nil {
+			t.Fatalf(`record %d: %v`, idx, err)
+		}
+
+		got, err := MarshalFixed(raw)
+		if err != nil {
+			t.Fatalf(`record %d: %v`, idx, err)
+		}
+
+		if !bytes.Equal(got, record) {
+			t.Fatalf("record %d:\n got '%s'\nwant '%s'", idx, latin1ToUTF8(got), latin1ToUTF8(record))
+		}
+	}
+}
+
+func TestDecodeToRawRoundTrip(t *testing.T) {
+	decoder := NewRecordDecoder()
+
+	for idx, record := range readTestRecords(t, testSourceFile(500)) {
+		addr, err := decoder.Decode(record)
+		if err != nil {
+			t.Fatalf(`record %d: %v`, idx, err)
+		}
+
+		raw, err := addr.ToRaw()
+		if err != nil {
+			t.Fatalf(`record %d: %v`, idx, err)
+		}
+
+		got, err := MarshalFixed(raw)
+		if err != nil {
+			t.Fatalf(`record %d: %v`, idx, err)
+		}
+
+		if !bytes.Equal(got, record) {
+			t.Fatalf("record %d:\n got '%s'\nwant '%s'", idx, latin1ToUTF8(got), latin1ToUTF8(record))
+		}
+	}
+}
+
+func TestToRawUpperCasesNames(t *testing.T) {
+	record := testRecords(1)[0]
+	setTestField(record[rawStreetNameFi.start:rawStreetNameFi.end], "\xc4imi\xf6nkatu 0")
+
+	addr, err := NewRecordDecoder().Decode(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := addr.ToRaw()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mixed case names don't round trip, they come back in upper case like in Posti's files
+	want := make([]byte, len(raw.StreetNameFi))
+	setTestField(want, "\xc4IMI\xd6NKATU 0")
+
+	if !bytes.Equal(raw.StreetNameFi[:], want) {
+		t.Fatalf(`got '%s', want '%s'`, latin1ToUTF8(raw.StreetNameFi[:]), latin1ToUTF8(want))
+	}
+}
+
+func TestBAFWriterRoundTrip(t *testing.T) {
+	source := testSourceFile(500)
+	decoder := NewRecordDecoder()
+
+	var buf bytes.Buffer
+	w := NewBAFWriter(&buf)
+
+	for idx, record := range readTestRecords(t, source) {
+		addr, err := decoder.Decode(record)
+		if err != nil {
+			t.Fatalf(`record %d: %v`, idx, err)
+		}
+
+		err = w.WriteStreet(addr)
+		if err != nil {
+			t.Fatalf(`record %d: %v`, idx, err)
+		}
+	}
+
+	err := w.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Writer ends every record with new line
+	if !bytes.Equal(buf.Bytes(), append(source, '\n')) {
+		t.Fatal(`written file differs from source`)
+	}
+}